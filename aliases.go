@@ -0,0 +1,60 @@
+package rbac
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returns an option that maps legacy role names to current ones, e.g. during a rename migration. Authorizers
+// resolve an alias to its role when it is added, so old tokens keep working. An alias may point at another alias.
+// NewRbacWithOptions returns an error if an alias shadows a role, points at an unknown role or is part of a cycle.
+func WithRoleAliases(aliases map[string]string) Option {
+	return func(o *options) {
+		o.roleAliases = make(map[string]string, len(aliases))
+		for alias, role := range aliases {
+			o.roleAliases[alias] = role
+		}
+	}
+}
+
+// Resolves every alias to the role it eventually points at.
+func (r *Rbac) resolveAliases(aliases map[string]string) error {
+	r.aliasToRole = make(map[string]string, len(aliases))
+	for _, alias := range sortedKeys(aliases) {
+		if _, ok := r.roleToPermissionSet[alias]; ok {
+			return fmt.Errorf("role alias %s shadows an existing role", alias)
+		}
+		path := []string{alias}
+		seen := map[string]bool{alias: true}
+		role := aliases[alias]
+		for {
+			path = append(path, role)
+			if seen[role] {
+				return fmt.Errorf("role alias cycle %s", strings.Join(path, " -> "))
+			}
+			next, ok := aliases[role]
+			if !ok {
+				break
+			}
+			seen[role] = true
+			role = next
+		}
+		if _, ok := r.roleToPermissionSet[role]; !ok {
+			if r.config.realm != "" {
+				// Aliases of roles of other realms are not part of a realm view.
+				continue
+			}
+			return fmt.Errorf("role alias %s points to unknown role %s", alias, role)
+		}
+		r.aliasToRole[alias] = role
+	}
+	return nil
+}
+
+// Returns the role an alias points at, or the role itself if it is not an alias.
+func (r *Rbac) resolveRole(role string) string {
+	if resolved, ok := r.aliasToRole[role]; ok {
+		return resolved
+	}
+	return role
+}
@@ -0,0 +1,51 @@
+package rbac_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_WithRoleAliases(t *testing.T) {
+	chains := func() []*rbac.RoleChain {
+		return []*rbac.RoleChain{rbac.Chain("use.Account").Add("Member", []string{"get"}).Add("Admin", []string{"update"})}
+	}
+	r, err := rbac.NewRbacWithOptions(chains(), rbac.WithRoleAliases(map[string]string{
+		"legacy.Admin":  "use.Account.Admin",
+		"ancient.Admin": "legacy.Admin",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	az := r.Authorizer("ancient.Admin")
+	if !az.HasRole("use.Account.Admin") || !az.HasPermission("update") {
+		t.Fatal("should resolve aliases of aliases")
+	}
+	az = r.Authorizer()
+	az.AddAsync(func() ([]string, error) {
+		return []string{"legacy.Admin"}, nil
+	})
+	if !az.HasPermission("update") || az.Err() != nil {
+		t.Fatal("should resolve async aliases")
+	}
+	if !r.AuthorizerFromHeader("legacy.Admin").HasPermission("update") {
+		t.Fatal("should resolve header aliases")
+	}
+	if invalid := r.ValidateAssignments(map[string][]string{"alice": {"legacy.Admin", "legacy.Owner"}}); !reflect.DeepEqual(invalid, map[string][]string{"alice": {"legacy.Owner"}}) {
+		t.Fatal("should resolve aliases when validating assignments, got", invalid)
+	}
+
+	tests := map[string]map[string]string{
+		"role alias legacy.Admin points to unknown role use.Account.Owner": {"legacy.Admin": "use.Account.Owner"},
+		"role alias cycle a -> b -> a":                                     {"a": "b", "b": "a"},
+		"role alias use.Account.Member shadows an existing role":           {"use.Account.Member": "use.Account.Admin"},
+	}
+	for want, aliases := range tests {
+		_, err := rbac.NewRbacWithOptions(chains(), rbac.WithRoleAliases(aliases))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("should fail with %q, got %v", want, err)
+		}
+	}
+}
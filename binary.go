@@ -0,0 +1,215 @@
+package rbac
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The version of the binary authorizer encoding.
+const binaryVersion = 2
+
+// The flags of a role in the binary authorizer encoding.
+const (
+	// The role is a default role that a later added role suppresses.
+	binaryRoleDefault = 1 << iota
+	// The role is followed by its expiry in unix nanoseconds.
+	binaryRoleExpiring
+)
+
+// Returns a compact binary encoding of the subject, roles, denies and scoped roles after waiting for async role
+// additions, to pass resolved roles between services. It starts with a version byte followed by the length
+// prefixed subject, the number of roles and each length prefixed role with its flags and expiry if it expires,
+// the number of denies and the length prefixed denies, and the number of resources and each length prefixed
+// resource with its roles. Decode it with Rbac.AuthorizerFromBinary. Returns an error if the authorizer has
+// errors or a matcher override, which the encoding cannot represent.
+func (a *Authorizer) MarshalBinary() ([]byte, error) {
+	a.wait()
+	if err := a.Err(); err != nil {
+		return nil, fmt.Errorf("cannot encode an authorizer with errors: %w", err)
+	}
+	if a.matcher != nil {
+		return nil, fmt.Errorf("cannot encode an authorizer with a matcher override")
+	}
+	data := []byte{binaryVersion}
+	data = appendBinaryString(data, a.subject)
+	roles := a.sortedRoles()
+	data = binary.AppendUvarint(data, uint64(len(roles)))
+	for _, role := range roles {
+		data = appendBinaryString(data, role)
+		flags := byte(0)
+		if explicit, ok := a.roles.Load(role); ok && !explicit.(bool) {
+			flags |= binaryRoleDefault
+		}
+		expiresAt, expiring := a.expiries.Load(role)
+		if expiring {
+			flags |= binaryRoleExpiring
+		}
+		data = append(data, flags)
+		if expiring {
+			data = binary.AppendVarint(data, expiresAt.(time.Time).UnixNano())
+		}
+	}
+	denies := sortedSyncKeys(&a.denies)
+	data = binary.AppendUvarint(data, uint64(len(denies)))
+	for _, permission := range denies {
+		data = appendBinaryString(data, permission)
+	}
+	resources := sortedSyncKeys(&a.scopes)
+	data = binary.AppendUvarint(data, uint64(len(resources)))
+	for _, resource := range resources {
+		value, _ := a.scopes.Load(resource)
+		scoped := sortedSyncKeys(value.(*sync.Map))
+		data = appendBinaryString(data, resource)
+		data = binary.AppendUvarint(data, uint64(len(scoped)))
+		for _, role := range scoped {
+			data = appendBinaryString(data, role)
+		}
+	}
+	return data, nil
+}
+
+// Returns an authorizer with the subject, roles, denies and scoped roles of a binary encoding from
+// Authorizer.MarshalBinary, or an error if the encoding is malformed, has an unsupported version or contains a
+// role that does not exist. The roles of the first version of the encoding are added like with Add.
+func (r *Rbac) AuthorizerFromBinary(data []byte) (*Authorizer, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty binary authorizer")
+	}
+	version := data[0]
+	if version != 1 && version != binaryVersion {
+		return nil, fmt.Errorf("unsupported binary authorizer version %d", data[0])
+	}
+	data = data[1:]
+	subject, data, err := readBinaryString(data)
+	if err != nil {
+		return nil, err
+	}
+	count, data, err := readBinaryCount(data, "role")
+	if err != nil {
+		return nil, err
+	}
+	a := r.Authorizer().WithSubject(subject)
+	if version == 1 {
+		roles := make([]string, 0, count)
+		for i := uint64(0); i < count; i++ {
+			var role string
+			if role, data, err = readBinaryString(data); err != nil {
+				return nil, err
+			}
+			if err := r.ValidateRole(role); err != nil {
+				return nil, err
+			}
+			roles = append(roles, role)
+		}
+		if len(data) != 0 {
+			return nil, fmt.Errorf("malformed binary authorizer with %d trailing bytes", len(data))
+		}
+		a.Add(roles...)
+		return a, nil
+	}
+	a.roles.Clear()
+	for i := uint64(0); i < count; i++ {
+		var role string
+		if role, data, err = readBinaryString(data); err != nil {
+			return nil, err
+		}
+		if err := r.ValidateRole(role); err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			return nil, fmt.Errorf("malformed binary authorizer role flags")
+		}
+		flags := data[0]
+		data = data[1:]
+		a.roles.Store(role, flags&binaryRoleDefault == 0)
+		if flags&binaryRoleExpiring != 0 {
+			expiresAt, n := binary.Varint(data)
+			if n <= 0 {
+				return nil, fmt.Errorf("malformed binary authorizer role expiry")
+			}
+			data = data[n:]
+			a.hasExpiring.Store(true)
+			a.expiries.Store(role, time.Unix(0, expiresAt))
+		}
+	}
+	if count, data, err = readBinaryCount(data, "deny"); err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < count; i++ {
+		var permission string
+		if permission, data, err = readBinaryString(data); err != nil {
+			return nil, err
+		}
+		// The denies are encoded transformed.
+		a.denies.Store(permission, true)
+	}
+	if count, data, err = readBinaryCount(data, "resource"); err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < count; i++ {
+		var resource string
+		if resource, data, err = readBinaryString(data); err != nil {
+			return nil, err
+		}
+		var roles uint64
+		if roles, data, err = readBinaryCount(data, "scoped role"); err != nil {
+			return nil, err
+		}
+		scope := &sync.Map{}
+		for j := uint64(0); j < roles; j++ {
+			var role string
+			if role, data, err = readBinaryString(data); err != nil {
+				return nil, err
+			}
+			if err := r.ValidateRole(role); err != nil {
+				return nil, err
+			}
+			scope.Store(role, true)
+		}
+		a.scopes.Store(resource, scope)
+	}
+	if len(data) != 0 {
+		return nil, fmt.Errorf("malformed binary authorizer with %d trailing bytes", len(data))
+	}
+	a.roleGeneration.Add(1)
+	return a, nil
+}
+
+// Appends the length prefixed string.
+func appendBinaryString(data []byte, s string) []byte {
+	data = binary.AppendUvarint(data, uint64(len(s)))
+	return append(data, s...)
+}
+
+// Returns the length prefixed string at the start of data and the rest of data.
+func readBinaryString(data []byte) (string, []byte, error) {
+	length, n := binary.Uvarint(data)
+	if n <= 0 || length > uint64(len(data)-n) {
+		return "", nil, fmt.Errorf("malformed binary authorizer string")
+	}
+	data = data[n:]
+	return string(data[:length]), data[length:], nil
+}
+
+// Returns the count of the named items at the start of data and the rest of data.
+func readBinaryCount(data []byte, name string) (uint64, []byte, error) {
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count > uint64(len(data)) {
+		return 0, nil, fmt.Errorf("malformed binary authorizer %s count", name)
+	}
+	return count, data[n:], nil
+}
+
+// Returns the sorted string keys of a sync map.
+func sortedSyncKeys(m *sync.Map) []string {
+	keys := []string{}
+	m.Range(func(key, _ interface{}) bool {
+		keys = append(keys, key.(string))
+		return true
+	})
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,96 @@
+package rbac_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_AuthorizerBinary(t *testing.T) {
+	az := Rbac.Authorizer("use.Account.Admin").WithSubject("user-1")
+	az.AddAsync(func() ([]string, error) {
+		return []string{"auth.Authenticated"}, nil
+	})
+	data, err := az.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Rbac.AuthorizerFromBinary(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Subject() != "user-1" || !reflect.DeepEqual(decoded.Roles(), []string{"auth.Authenticated", "use.Account.Admin"}) {
+		t.Fatalf("should round trip, got %q %v", decoded.Subject(), decoded.Roles())
+	}
+
+	unknown, _ := Rbac.Authorizer("use.Account.Owner").MarshalBinary()
+	malformed := [][]byte{nil, {2}, data[:len(data)-1], append(append([]byte{}, data...), 0), unknown}
+	for _, data := range malformed {
+		if _, err := Rbac.AuthorizerFromBinary(data); err == nil {
+			t.Fatalf("should reject %v", data)
+		}
+	}
+}
+
+func Test_AuthorizerBinary_State(t *testing.T) {
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("a").Add("Member", []string{"read"}).Add("Admin", []string{"write"}),
+		rbac.Chain("auth").Add("Anonymous", []string{"list"}),
+	}, rbac.WithDefaultRoles("auth.Anonymous"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	az := r.Authorizer()
+	az.AddExpiring("a.Admin", time.Now().Add(20*time.Millisecond))
+	az.AddScoped("doc-1", "a.Admin")
+	az.AddDeniesAsync(func() ([]string, error) { return []string{"read"}, nil })
+	data, err := az.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := r.AuthorizerFromBinary(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.HasPermission("list") || decoded.HasPermission("read") || !decoded.HasPermission("write") {
+		t.Fatal("should keep suppressed default roles and denies")
+	}
+	if !decoded.HasPermissionOn("doc-1", "write") {
+		t.Fatal("should keep scoped roles")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if decoded.HasPermission("write") || az.HasPermission("write") {
+		t.Fatal("should keep the expiry of expiring roles")
+	}
+
+	anonymous, _ := r.Authorizer().MarshalBinary()
+	decoded, err = r.AuthorizerFromBinary(anonymous)
+	if err != nil || !decoded.HasPermission("list") {
+		t.Fatal("should keep default roles, got", err)
+	}
+	if decoded.Add("a.Member"); decoded.HasPermission("list") {
+		t.Fatal("should keep default roles suppressible")
+	}
+
+	failed := r.Authorizer()
+	failed.AddAsync(func() ([]string, error) { return nil, errors.New("down") })
+	if _, err := failed.MarshalBinary(); err == nil {
+		t.Fatal("should reject an authorizer with errors")
+	}
+	if _, err := r.Authorizer().WithMatcher(rbac.ExactMatcher{}).MarshalBinary(); err == nil {
+		t.Fatal("should reject an authorizer with a matcher override")
+	}
+
+	v1 := []byte{1}
+	v1 = binary.AppendUvarint(v1, 0)
+	v1 = binary.AppendUvarint(v1, 1)
+	v1 = binary.AppendUvarint(v1, uint64(len("a.Member")))
+	v1 = append(v1, "a.Member"...)
+	if decoded, err := r.AuthorizerFromBinary(v1); err != nil || !decoded.HasPermission("read") || decoded.HasPermission("list") {
+		t.Fatal("should decode the first version, got", err)
+	}
+}
@@ -0,0 +1,81 @@
+package rbac_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+// Returns a chain of independent roles that each give a slice of the permissions, and the role names.
+func bloomChain(roleCount, permissionsPerRole int) (*rbac.RoleChain, []string) {
+	chain := rbac.Chain("bench")
+	roles := make([]string, roleCount)
+	for i := range roles {
+		permissions := make([]string, permissionsPerRole)
+		for j := range permissions {
+			permissions[j] = fmt.Sprintf("perm%d", i*permissionsPerRole+j)
+		}
+		chain.AddIndependent(fmt.Sprintf("Role%d", i), permissions)
+		roles[i] = fmt.Sprintf("bench.Role%d", i)
+	}
+	return chain, roles
+}
+
+func Test_WithBloomFilter(t *testing.T) {
+	chain, roles := bloomChain(20, 50)
+	chain.AddSuperAdmin("Root")
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{chain}, rbac.WithBloomFilter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	az := r.Authorizer(roles[:10]...)
+	for i := 0; i < 1000; i++ {
+		permission := fmt.Sprintf("perm%d", i)
+		if az.HasPermission(permission) != (i < 500) {
+			t.Fatal("should match the exact check for", permission)
+		}
+	}
+	az.Add(roles[10])
+	if !az.HasPermission("perm500") {
+		t.Fatal("should rebuild the filter after a role is added")
+	}
+	az.Add("bench.Root")
+	if !az.HasPermission("perm999") || !az.HasPermission("unknown") {
+		t.Fatal("should skip the filter for a super admin")
+	}
+	glob, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("billing").Add("Admin", []string{"billing.*"})}, rbac.WithBloomFilter(), rbac.WithMatcher(rbac.GlobMatcher{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !glob.Authorizer("billing.Admin").HasPermission("billing.read") {
+		t.Fatal("should skip the filter with a matcher")
+	}
+}
+
+func BenchmarkBloomFilter(b *testing.B) {
+	for _, bloom := range []bool{false, true} {
+		b.Run(fmt.Sprintf("bloom=%v", bloom), func(b *testing.B) {
+			chain, roles := bloomChain(400, 10)
+			opts := []rbac.Option{}
+			if bloom {
+				opts = append(opts, rbac.WithBloomFilter())
+			}
+			// The checked permission is given by many roles the authorizer does not have.
+			other := rbac.Chain("other")
+			for i := 0; i < 200; i++ {
+				other.AddIndependent(fmt.Sprintf("Role%d", i), []string{"perm3990", "perm3991", "perm3992"})
+			}
+			r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{chain, other}, opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			az := r.Authorizer(roles[:200]...)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				az.HasPermission("perm3990")
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package rbac
+
+// Checks permissions and roles, implemented by Authorizer and its decorators.
+type Checker interface {
+	// Returns whether the specified permission is given.
+	HasPermission(permission string) bool
+	// Returns whether the given role is held.
+	HasRole(role string) bool
+}
+
+var _ Checker = (*Authorizer)(nil)
+
+// A checker that logs every permission decision of an authorizer.
+type loggingChecker struct {
+	authorizer *Authorizer
+	log        func(permission string, granted bool)
+}
+
+// Returns a checker that delegates to the authorizer and calls log with every HasPermission decision.
+func LoggingAuthorizer(a *Authorizer, log func(permission string, granted bool)) Checker {
+	return &loggingChecker{
+		authorizer: a,
+		log:        log,
+	}
+}
+
+// Returns whether the authorizer gives the permission and logs the decision.
+func (c *loggingChecker) HasPermission(permission string) bool {
+	granted := c.authorizer.HasPermission(permission)
+	c.log(permission, granted)
+	return granted
+}
+
+// Returns whether the authorizer holds the role.
+func (c *loggingChecker) HasRole(role string) bool {
+	return c.authorizer.HasRole(role)
+}
+
+// A checker that fails closed: a panic during a check, e.g. in a condition function or custom matcher,
+// is recovered, reported and treated as a deny instead of crashing the request.
+type SafeChecker struct {
+	// The checker checks are delegated to.
+	checker Checker
+	// Called with the recovered value of every panic.
+	onPanic func(recovered any)
+}
+
+// Returns a checker that delegates to c and denies any check that panics after reporting it to onPanic.
+// A nil onPanic only denies.
+func NewSafeChecker(c Checker, onPanic func(recovered any)) *SafeChecker {
+	return &SafeChecker{
+		checker: c,
+		onPanic: onPanic,
+	}
+}
+
+// Returns whether the permission is given, false if the check panicked.
+func (c *SafeChecker) HasPermission(permission string) (granted bool) {
+	defer c.recover(&granted)
+	return c.checker.HasPermission(permission)
+}
+
+// Returns whether the role is held, false if the check panicked.
+func (c *SafeChecker) HasRole(role string) (held bool) {
+	defer c.recover(&held)
+	return c.checker.HasRole(role)
+}
+
+// Returns whether the policy is satisfied, false if the check panicked.
+func (c *SafeChecker) Satisfies(p Policy) (satisfied bool) {
+	defer c.recover(&satisfied)
+	if s, ok := c.checker.(interface{ Satisfies(p Policy) bool }); ok {
+		return s.Satisfies(p)
+	}
+	return p.Eval(c.checker.HasPermission)
+}
+
+// Returns whether the permission is given for the attributes, false if a condition panicked.
+// Falls back to HasPermission if the checker does not support attributes.
+func (c *SafeChecker) HasPermissionWithAttrs(permission string, attrs map[string]any) (granted bool) {
+	defer c.recover(&granted)
+	if s, ok := c.checker.(interface {
+		HasPermissionWithAttrs(permission string, attrs map[string]any) bool
+	}); ok {
+		return s.HasPermissionWithAttrs(permission, attrs)
+	}
+	return c.checker.HasPermission(permission)
+}
+
+// Recovers from a panic, reporting it and setting the result to false.
+func (c *SafeChecker) recover(result *bool) {
+	if recovered := recover(); recovered != nil {
+		*result = false
+		if c.onPanic != nil {
+			c.onPanic(recovered)
+		}
+	}
+}
+
+// A checker that always gives the same answer.
+type staticChecker bool
+
+// Returns the static answer.
+func (c staticChecker) HasPermission(permission string) bool {
+	return bool(c)
+}
+
+// Returns the static answer.
+func (c staticChecker) HasRole(role string) bool {
+	return bool(c)
+}
+
+// Returns a checker that gives every permission and role, to bypass authorization in local development and
+// tests. Never use it in production.
+func AllowAllAuthorizer() Checker {
+	return staticChecker(true)
+}
+
+// Returns a checker that denies every permission and role, for tests and local development.
+func DenyAllAuthorizer() Checker {
+	return staticChecker(false)
+}
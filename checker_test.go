@@ -0,0 +1,57 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_LoggingAuthorizer(t *testing.T) {
+	logged := map[string]bool{}
+	var checker rbac.Checker = rbac.LoggingAuthorizer(Rbac.Authorizer("use.Account.Member"), func(permission string, granted bool) {
+		logged[permission] = granted
+	})
+	if !checker.HasPermission("get") || checker.HasPermission("delete") {
+		t.Fatal("should delegate permission checks")
+	}
+	if !checker.HasRole("use.Account.Member") {
+		t.Fatal("should delegate role checks")
+	}
+	if len(logged) != 2 || !logged["get"] || logged["delete"] {
+		t.Fatalf("should log every decision, got %v", logged)
+	}
+}
+
+func Test_SafeChecker(t *testing.T) {
+	chain := rbac.Chain("doc").Add("Member", []string{"view"})
+	chain.AddConditional("Member", "edit", func(attrs map[string]any) bool {
+		return attrs["ownerID"].(string) == attrs["userID"].(string)
+	})
+	r, err := rbac.NewRbac(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	panics := 0
+	safe := rbac.NewSafeChecker(r.Authorizer("doc.Member"), func(recovered any) {
+		panics++
+	})
+	if safe.HasPermissionWithAttrs("edit", map[string]any{}) {
+		t.Fatal("should deny when a condition panics")
+	}
+	if panics != 1 {
+		t.Fatalf("should report the panic, got %d", panics)
+	}
+	if !safe.HasPermission("view") || !safe.Satisfies(rbac.Perm("view")) || !safe.HasRole("doc.Member") {
+		t.Fatal("should delegate checks that do not panic")
+	}
+}
+
+func Test_AllowAllAuthorizer(t *testing.T) {
+	allow, deny := rbac.AllowAllAuthorizer(), rbac.DenyAllAuthorizer()
+	if !allow.HasPermission("anything") || !allow.HasRole("any.Role") {
+		t.Fatal("should allow everything")
+	}
+	if deny.HasPermission("anything") || deny.HasRole("any.Role") {
+		t.Fatal("should deny everything")
+	}
+}
@@ -0,0 +1,203 @@
+// Command rbacgen reads a JSON or YAML rbac definition and generates a Go file
+// with constants for every permission and flattened role name, so typos become
+// compile errors instead of silent denials.
+//
+// The definition lists the chains in the same shape as the Chain(...).Add(...)
+// builder, with the raw (non-extended) permissions of each role:
+//
+//	{
+//	  "chains": [
+//	    {
+//	      "name": "auth",
+//	      "description": "Whether the caller is signed in",
+//	      "roles": [
+//	        {"id": "Unauthenticated", "permissions": ["list"]},
+//	        {"id": "Authenticated", "permissions": ["create"]}
+//	      ]
+//	    }
+//	  ],
+//	  "permission_descriptions": {"create": "Creates an account"}
+//	}
+//
+// A definition whose path ends in .yaml or .yml is read as YAML with the same
+// keys:
+//
+//	chains:
+//	  - name: auth
+//	    roles:
+//	      - id: Unauthenticated
+//	        permissions: [list]
+//
+// Permission descriptions become the doc comments of their constants.
+//
+// Typical usage via go generate:
+//
+//	//go:generate go run github.com/acudac-com/rbac-go/cmd/rbacgen -in rbac.json -out rbacdef/rbacdef.go -pkg rbacdef
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+// A json or yaml rbac definition.
+type definition struct {
+	// The chains in registration order.
+	Chains []chainDefinition `json:"chains" yaml:"chains"`
+	// What permissions do.
+	PermissionDescriptions map[string]string `json:"permission_descriptions" yaml:"permission_descriptions"`
+}
+
+// A json or yaml chain definition.
+type chainDefinition struct {
+	// The name of the chain.
+	Name string `json:"name" yaml:"name"`
+	// What the chain represents, for documentation only.
+	Description string `json:"description" yaml:"description"`
+	// The roles of the chain in extension order.
+	Roles []roleDefinition `json:"roles" yaml:"roles"`
+}
+
+// A json or yaml role definition.
+type roleDefinition struct {
+	// The id of the role in its chain.
+	Id string `json:"id" yaml:"id"`
+	// The permissions the role adds to the previous roles in its chain.
+	Permissions []string `json:"permissions" yaml:"permissions"`
+}
+
+func main() {
+	in := flag.String("in", "", "path of the json or yaml rbac definition")
+	out := flag.String("out", "", "path of the generated go file (stdout if empty)")
+	pkg := flag.String("pkg", "rbacdef", "package name of the generated go file")
+	flag.Parse()
+	if err := run(*in, *out, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "rbacgen:", err)
+		os.Exit(1)
+	}
+}
+
+// Reads the definition at in and writes the generated constants to out.
+func run(in, out, pkg string) error {
+	if in == "" {
+		return fmt.Errorf("missing -in flag")
+	}
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	def, err := parse(in, data)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", in, err)
+	}
+	src, err := generate(def, pkg)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
+
+// Returns the definition in data, read as yaml if the path ends in .yaml or .yml and as json otherwise.
+func parse(path string, data []byte) (definition, error) {
+	def := definition{}
+	if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
+		return def, yaml.Unmarshal(data, &def)
+	}
+	return def, json.Unmarshal(data, &def)
+}
+
+// Returns the formatted go source with the permission and role constants of the definition.
+func generate(def definition, pkg string) ([]byte, error) {
+	config := rbac.Config{PermissionDescriptions: def.PermissionDescriptions}
+	permissionSet := map[string]bool{}
+	roleSet := map[string]bool{}
+	for _, chainDef := range def.Chains {
+		chainConfig := rbac.ChainConfig{Name: chainDef.Name, Description: chainDef.Description}
+		for i, roleDef := range chainDef.Roles {
+			roleConfig := rbac.RoleConfig{Id: roleDef.Id, Permissions: roleDef.Permissions}
+			if i > 0 {
+				roleConfig.Extends = chainDef.Roles[i-1].Id
+			}
+			chainConfig.Roles = append(chainConfig.Roles, roleConfig)
+			roleSet[chainDef.Name+"."+roleDef.Id] = true
+			for _, permission := range roleDef.Permissions {
+				permissionSet[permission] = true
+			}
+		}
+		config.Chains = append(config.Chains, chainConfig)
+	}
+	if _, err := config.Build(); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by rbacgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(buf, "package %s\n\n", pkg)
+	if err := writeConsts(buf, "Permissions.", "Perm", permissionSet, def.PermissionDescriptions); err != nil {
+		return nil, err
+	}
+	if err := writeConsts(buf, "Roles in the format {chainName}.{roleId}.", "Role", roleSet, nil); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// Writes a sorted const block with an identifier of the given prefix for every value, documented by its
+// description if it has one.
+func writeConsts(buf *bytes.Buffer, comment, prefix string, valueSet map[string]bool, descriptions map[string]string) error {
+	if len(valueSet) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(valueSet))
+	for value := range valueSet {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	identToValue := map[string]string{}
+	fmt.Fprintf(buf, "// %s\nconst (\n", comment)
+	for _, value := range values {
+		if identifier(value) == "" {
+			return fmt.Errorf("%q does not contain any letters or digits", value)
+		}
+		ident := prefix + identifier(value)
+		if other, ok := identToValue[ident]; ok {
+			return fmt.Errorf("%q and %q both generate the identifier %s", other, value, ident)
+		}
+		identToValue[ident] = value
+		if description, ok := descriptions[value]; ok {
+			fmt.Fprintf(buf, "\t// %s\n", strings.ReplaceAll(description, "\n", " "))
+		}
+		fmt.Fprintf(buf, "\t%s = %q\n", ident, value)
+	}
+	fmt.Fprintf(buf, ")\n\n")
+	return nil
+}
+
+// Returns the camel cased identifier of a value, e.g. "use.Account.Member" becomes "UseAccountMember".
+func identifier(value string) string {
+	parts := strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	ident := ""
+	for _, part := range parts {
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		ident += string(runes)
+	}
+	return ident
+}
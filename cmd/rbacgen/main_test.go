@@ -0,0 +1,101 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var def = definition{
+	Chains: []chainDefinition{
+		{Name: "use.Account", Roles: []roleDefinition{
+			{Id: "Member", Permissions: []string{"get"}},
+			{Id: "Admin", Permissions: []string{"update", "delete"}},
+		}},
+		{Name: "auth", Roles: []roleDefinition{
+			{Id: "Unauthenticated", Permissions: []string{"list"}},
+		}},
+	},
+}
+
+func Test_Generate(t *testing.T) {
+	src, err := generate(def, "rbacdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `// Code generated by rbacgen. DO NOT EDIT.
+
+package rbacdef
+
+// Permissions.
+const (
+	PermDelete = "delete"
+	PermGet    = "get"
+	PermList   = "list"
+	PermUpdate = "update"
+)
+
+// Roles in the format {chainName}.{roleId}.
+const (
+	RoleAuthUnauthenticated = "auth.Unauthenticated"
+	RoleUseAccountAdmin     = "use.Account.Admin"
+	RoleUseAccountMember    = "use.Account.Member"
+)
+`
+	if string(src) != want {
+		t.Fatalf("unexpected output:\n%s", src)
+	}
+}
+
+func Test_GenerateCollision(t *testing.T) {
+	collision := definition{Chains: []chainDefinition{
+		{Name: "auth", Roles: []roleDefinition{
+			{Id: "Member", Permissions: []string{"account.read", "account_read"}},
+		}},
+	}}
+	_, err := generate(collision, "rbacdef")
+	if err == nil || !strings.Contains(err.Error(), "PermAccountRead") {
+		t.Fatalf("expected identifier collision error, got %v", err)
+	}
+}
+
+func Test_GenerateDescriptions(t *testing.T) {
+	described := definition{
+		Chains: []chainDefinition{
+			{Name: "billing", Roles: []roleDefinition{
+				{Id: "Admin", Permissions: []string{"billing.void"}},
+			}},
+		},
+		PermissionDescriptions: map[string]string{"billing.void": "Voids an issued invoice"},
+	}
+	src, err := generate(described, "rbacdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "\t// Voids an issued invoice\n\tPermBillingVoid = \"billing.void\"\n") {
+		t.Fatalf("should document the constant with its description:\n%s", src)
+	}
+	described.PermissionDescriptions["billing.refund"] = "Refunds an invoice"
+	if _, err := generate(described, "rbacdef"); err == nil || err.Error() != "description of unknown permission billing.refund" {
+		t.Fatalf("should validate the descriptions, got %v", err)
+	}
+}
+
+func Test_Parse(t *testing.T) {
+	fromJSON, err := parse("rbac.json", []byte(`{"chains": [{"name": "auth", "roles": [{"id": "Member", "permissions": ["get"]}]}], "permission_descriptions": {"get": "Gets"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"rbac.yaml", "rbac.yml"} {
+		fromYAML, err := parse(path, []byte("chains:\n  - name: auth\n    roles:\n      - id: Member\n        permissions: [get]\npermission_descriptions:\n  get: Gets\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(fromYAML, fromJSON) {
+			t.Fatalf("should read %s like json, got %+v", path, fromYAML)
+		}
+	}
+	if _, err := parse("rbac.yaml", []byte("chains: {")); err == nil {
+		t.Fatal("should reject invalid yaml")
+	}
+}
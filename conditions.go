@@ -0,0 +1,97 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+)
+
+// A permission a role only gives if its condition passes against the attributes of a check.
+type condition struct {
+	// The id of the role in its chain.
+	roleId string
+	// The conditional permission.
+	permission string
+	// Returns whether the permission is given for the attributes.
+	cond func(attrs map[string]any) bool
+}
+
+// Makes the role with the given id, which must be added to the chain, give the permission only if cond passes
+// against the attributes passed to Authorizer.HasPermissionWithAttrs, e.g. to only edit own records:
+//
+//	chain.AddConditional("Member", "edit", func(attrs map[string]any) bool {
+//		return attrs["ownerID"] == attrs["userID"]
+//	})
+//
+// The conditional permission is not extended by later roles in the chain and is never given by
+// HasPermission, which has no attributes to evaluate. Several conditions on the same permission must all pass.
+// A permission the role also gives unconditionally behaves as usual.
+func (c *RoleChain) AddConditional(id string, permission string, cond func(attrs map[string]any) bool) *RoleChain {
+	c.conditions = append(c.conditions, &condition{
+		roleId:     id,
+		permission: permission,
+		cond:       cond,
+	})
+	return c
+}
+
+// Registers the conditional permissions of the chain, which must refer to roles of the chain, and returns an
+// error for every invalid one.
+func (r *Rbac) addConditions(chain *RoleChain) []error {
+	errs := []error{}
+	for _, c := range chain.conditions {
+		roleName := r.flatten(chain.name, c.roleId)
+		permission := r.transformPermission(c.permission)
+		if !r.chainToRoleIdSet[chain.name][c.roleId] {
+			errs = append(errs, fmt.Errorf("conditional permission %s for unknown role %s", c.permission, roleName))
+			continue
+		}
+		if c.permission == "" || permission == "" {
+			errs = append(errs, fmt.Errorf("role %s has an empty conditional permission", roleName))
+			continue
+		}
+		if r.config.permissionValidator != nil {
+			if err := r.config.permissionValidator(permission); err != nil {
+				errs = append(errs, fmt.Errorf("role %s permission %q: %w", roleName, permission, err))
+				continue
+			}
+		}
+		if _, ok := r.permissionToRoleConditions[permission]; !ok {
+			r.permissionToRoleConditions[permission] = map[string][]func(attrs map[string]any) bool{}
+		}
+		r.permissionToRoleConditions[permission][roleName] = append(r.permissionToRoleConditions[permission][roleName], c.cond)
+	}
+	return errs
+}
+
+// Returns whether one of the roles give the specified permission, either unconditionally or through a
+// conditional permission whose conditions all pass against the attributes.
+func (a *Authorizer) HasPermissionWithAttrs(permission string, attrs map[string]any) bool {
+	a.wait()
+	return a.decide(context.Background(), a.rbac.transformPermission(permission), func(permission string) bool {
+		return a.conditionsGive(permission, attrs)
+	})
+}
+
+// Returns whether a role gives the transformed permission through a conditional permission whose conditions all
+// pass against the attributes, unless it is denied.
+func (a *Authorizer) conditionsGive(permission string, attrs map[string]any) bool {
+	if a.isDenied(permission) {
+		return false
+	}
+	for role, conds := range a.rbac.permissionToRoleConditions[permission] {
+		if _, ok := a.roles.Load(role); ok && a.rbac.roleEnabled(role) && allPass(conds, attrs) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns whether all conditions pass against the attributes.
+func allPass(conds []func(attrs map[string]any) bool, attrs map[string]any) bool {
+	for _, cond := range conds {
+		if !cond(attrs) {
+			return false
+		}
+	}
+	return true
+}
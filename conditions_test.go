@@ -0,0 +1,42 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_HasPermissionWithAttrs(t *testing.T) {
+	chain := rbac.Chain("doc")
+	chain.Add("Member", []string{"view"})
+	chain.Add("Admin", []string{"edit"})
+	chain.AddConditional("Member", "edit", func(attrs map[string]any) bool {
+		return attrs["ownerID"] == attrs["userID"]
+	})
+	r, err := rbac.NewRbac(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	member := r.Authorizer("doc.Member")
+	if !member.HasPermissionWithAttrs("edit", map[string]any{"ownerID": "u1", "userID": "u1"}) {
+		t.Fatal("member should edit own records")
+	}
+	if member.HasPermissionWithAttrs("edit", map[string]any{"ownerID": "u2", "userID": "u1"}) {
+		t.Fatal("member should not edit records of others")
+	}
+	if member.HasPermission("edit") {
+		t.Fatal("conditional permission should not be given without attributes")
+	}
+	if !member.HasPermissionWithAttrs("view", nil) {
+		t.Fatal("unconditional permissions should behave as usual")
+	}
+	if !r.Authorizer("doc.Admin").HasPermissionWithAttrs("edit", map[string]any{"ownerID": "u2", "userID": "u1"}) {
+		t.Fatal("admin should edit any record")
+	}
+
+	unknown := rbac.Chain("doc").Add("Member", nil).AddConditional("Owner", "edit", func(map[string]any) bool { return true })
+	if _, err := rbac.NewRbac(unknown); err == nil {
+		t.Fatal("should error for a condition on an unknown role")
+	}
+}
@@ -0,0 +1,155 @@
+package rbac
+
+import "fmt"
+
+// A structured definition of the chains of an rbac, e.g. decoded from a config file. The loaders produce a
+// Config, so every format shares the validation of Build.
+type Config struct {
+	// The chains in registration order.
+	Chains []ChainConfig `json:"chains" toml:"chains"`
+	// What permissions do, see WithPermissionDescriptions.
+	PermissionDescriptions map[string]string `json:"permission_descriptions,omitempty" toml:"permission_descriptions,omitempty"`
+}
+
+// A structured definition of a chain.
+type ChainConfig struct {
+	// The name of the chain.
+	Name string `json:"name" toml:"name"`
+	// What the chain represents, for documentation only.
+	Description string `json:"description,omitempty" toml:"description,omitempty"`
+	// The realm the chain belongs to, empty if none.
+	Realm string `json:"realm,omitempty" toml:"realm,omitempty"`
+	// The roles of the chain in order.
+	Roles []RoleConfig `json:"roles" toml:"roles"`
+}
+
+// A structured definition of a role.
+type RoleConfig struct {
+	// The id of the role in its chain.
+	Id string `json:"id" toml:"id"`
+	// The id of a previous role in the chain whose permissions the role extends, empty if none.
+	Extends string `json:"extends,omitempty" toml:"extends,omitempty"`
+	// The permissions the role adds to the role it extends.
+	Permissions []string `json:"permissions" toml:"permissions"`
+	// Whether the role gives every permission, like a role added with RoleChain.AddSuperAdmin.
+	SuperAdmin bool `json:"super_admin,omitempty" toml:"super_admin,omitempty"`
+	// The permissions the role denies, like a role added with RoleChain.AddDeny.
+	Deny []string `json:"deny,omitempty" toml:"deny,omitempty"`
+	// Whether the role passes every check, like a role passed to WithPrivilegedRoles. Its permissions still
+	// only list the ones it gives.
+	Privileged bool `json:"privileged,omitempty" toml:"privileged,omitempty"`
+}
+
+// Returns a new role-based access controller made up of the chains of the config and configured by the options.
+// A role may only extend a role listed before it in its chain. The chains are validated like in NewRbacWithOptions.
+func (c Config) Build(opts ...Option) (*Rbac, error) {
+	chains := make([]*RoleChain, 0, len(c.Chains))
+	// The chain name and id of every privileged role.
+	privileged := [][2]string{}
+	for _, cc := range c.Chains {
+		chain := Chain(cc.Name).Describe(cc.Description).Realm(cc.Realm)
+		idToPermissions := map[string][]string{}
+		for _, rc := range cc.Roles {
+			permissions := append([]string{}, rc.Permissions...)
+			if rc.Extends != "" {
+				parent, ok := idToPermissions[rc.Extends]
+				if !ok {
+					return nil, fmt.Errorf("role %s.%s extends unknown role %s", cc.Name, rc.Id, rc.Extends)
+				}
+				permissions = append(permissions, parent...)
+			}
+			idToPermissions[rc.Id] = permissions
+			if rc.Privileged {
+				privileged = append(privileged, [2]string{cc.Name, rc.Id})
+			}
+			chain.roles = append(chain.roles, &Role{
+				Id:          rc.Id,
+				Permissions: permissions,
+				independent: true,
+				parent:      rc.Extends,
+				superAdmin:  rc.SuperAdmin,
+				deny:        append([]string{}, rc.Deny...),
+			})
+		}
+		chains = append(chains, chain)
+	}
+	if len(c.PermissionDescriptions) > 0 {
+		opts = append([]Option{WithPermissionDescriptions(c.PermissionDescriptions)}, opts...)
+	}
+	if len(privileged) > 0 {
+		// Appended after the options so privileged roles passed to Build are kept.
+		opts = append(append([]Option{}, opts...), func(o *options) {
+			separator := "."
+			if o.roleSeparator != nil {
+				separator = *o.roleSeparator
+			}
+			for _, role := range privileged {
+				o.privilegedRoles = append(o.privilegedRoles, role[0]+separator+role[1])
+			}
+		})
+	}
+	return NewRbacWithOptions(chains, opts...)
+}
+
+// Returns the canonical config of the chains, from which Build creates an equal rbac, keeping the order of chains
+// and roles. Every role lists the permissions it adds to the role it extends, with all-except roles and chains
+// extending other chains expanded. Privileged roles are flagged and list the permissions they give. Routes and
+// options other than the permission descriptions and privileged roles are not part of the config, and gated roles
+// and conditional permissions are an error as they are functions.
+func (r *Rbac) Config() (Config, error) {
+	if len(r.permissionToRoleConditions) > 0 {
+		permission := sortedKeys(r.permissionToRoleConditions)[0]
+		role := sortedKeys(r.permissionToRoleConditions[permission])[0]
+		return Config{}, fmt.Errorf("role %s has a conditional permission %s that cannot be configured", role, permission)
+	}
+	privileged := map[string]bool{}
+	for _, role := range r.config.privilegedRoles {
+		privileged[r.resolveRole(role)] = true
+	}
+	c := Config{Chains: make([]ChainConfig, 0, len(r.chainNames))}
+	if len(r.permissionToDescription) > 0 {
+		c.PermissionDescriptions = make(map[string]string, len(r.permissionToDescription))
+		for permission, description := range r.permissionToDescription {
+			c.PermissionDescriptions[r.rawPermissions([]string{permission})[0]] = description
+		}
+	}
+	for _, chain := range r.chainNames {
+		cc := ChainConfig{
+			Name:        chain,
+			Description: r.chainToDescription[chain],
+			Realm:       r.chainToRealm[chain],
+			Roles:       []RoleConfig{},
+		}
+		for _, role := range r.chainToRoleNames[chain] {
+			if _, ok := r.roleToGate[role]; ok {
+				return Config{}, fmt.Errorf("role %s is gated and cannot be configured", role)
+			}
+			_, id, _ := r.SplitRole(role)
+			deny := r.rawPermissions(sortedKeys(r.roleToDenySet[role]))
+			if len(deny) == 0 {
+				deny = nil
+			}
+			if r.superAdminSet[role] && !privileged[role] {
+				cc.Roles = append(cc.Roles, RoleConfig{Id: id, Permissions: []string{}, SuperAdmin: true, Deny: deny})
+				continue
+			}
+			rc := RoleConfig{Id: id, Permissions: r.rawPermissions(r.RoleDelta(role)), Deny: deny, Privileged: privileged[role]}
+			if parent, ok := r.roleToParent[role]; privileged[role] || (ok && privileged[parent]) {
+				// RoleDelta counts every permission for a privileged role, not the ones it gives.
+				added := map[string]bool{}
+				for permission := range r.grantedPermissionSet(role) {
+					if !ok || !r.grantedPermissionSet(parent)[permission] {
+						added[permission] = true
+					}
+				}
+				rc.Permissions = r.rawPermissions(sortedKeys(added))
+			}
+			if parent, ok := r.roleToParent[role]; ok {
+				_, rc.Extends, _ = r.SplitRole(parent)
+			}
+			cc.Roles = append(cc.Roles, rc)
+		}
+		c.Chains = append(c.Chains, cc)
+	}
+	return c, nil
+}
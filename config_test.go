@@ -0,0 +1,96 @@
+package rbac_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_ConfigBuild(t *testing.T) {
+	c := rbac.Config{Chains: []rbac.ChainConfig{{
+		Name:  "use.Account",
+		Realm: "billing",
+		Roles: []rbac.RoleConfig{
+			{Id: "Member", Permissions: []string{"get"}},
+			{Id: "Admin", Extends: "Member", Permissions: []string{"update"}},
+			{Id: "Auditor", Permissions: []string{"audit"}},
+			{Id: "Root", SuperAdmin: true},
+		},
+	}}}
+	r, err := c.Build(rbac.WithVersion("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.EffectivePermissionsForRole("use.Account.Admin"), []string{"get", "update"}) || !reflect.DeepEqual(r.EffectivePermissionsForRole("use.Account.Auditor"), []string{"audit"}) {
+		t.Fatal("should only extend the extended role")
+	}
+	if !r.Authorizer("use.Account.Root").HasPermission("anything") || r.Version() != "v1" || r.RoleRealm("use.Account.Root") != "billing" {
+		t.Fatal("should build super admins, realms and apply the options")
+	}
+	c.Chains[0].Roles[1].Extends = "Owner"
+	if _, err := c.Build(); err == nil || err.Error() != "role use.Account.Admin extends unknown role Owner" {
+		t.Fatal("should reject extending an unknown role, got", err)
+	}
+	if _, err := (rbac.Config{}).Build(); err == nil || err.Error() != "no role chains provided" {
+		t.Fatal("should validate like NewRbac, got", err)
+	}
+}
+
+func Test_RbacConfig(t *testing.T) {
+	c, err := Rbac.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := rbac.Config{Chains: []rbac.ChainConfig{
+		{Name: "auth", Roles: []rbac.RoleConfig{
+			{Id: "Unauthenticated", Permissions: []string{"list"}},
+			{Id: "Authenticated", Extends: "Unauthenticated", Permissions: []string{"create"}},
+		}},
+		{Name: "use.Account", Roles: []rbac.RoleConfig{
+			{Id: "Member", Permissions: []string{"get"}},
+			{Id: "Admin", Extends: "Member", Permissions: []string{"delete", "update"}},
+		}},
+	}}
+	if !reflect.DeepEqual(c, want) {
+		t.Fatalf("should return the canonical config, got %+v", c)
+	}
+	built, err := c.Build()
+	if err != nil || !built.Equal(Rbac) {
+		t.Fatal("should build an equal rbac", err)
+	}
+}
+
+func Test_RbacConfig_Privileged(t *testing.T) {
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("a").Add("M", []string{"read"}).Add("A", []string{"write"}),
+		rbac.Chain("b").Add("O", []string{"other"}),
+	}, rbac.WithPrivilegedRoles("a.M"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := r.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := c.Chains[0].Roles[0]; !m.Privileged || !reflect.DeepEqual(m.Permissions, []string{"read"}) {
+		t.Fatalf("should flag the privileged role with the permissions it gives, got %+v", m)
+	}
+	if a := c.Chains[0].Roles[1]; a.Privileged || !reflect.DeepEqual(a.Permissions, []string{"write"}) {
+		t.Fatalf("should not flag the roles extending it, got %+v", a)
+	}
+	data, err := r.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := rbac.LoadRbac(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Equal(r) || !loaded.Authorizer("a.M").IsPrivileged() {
+		t.Fatal("should round trip the privileged role")
+	}
+	if loaded.Authorizer("a.A").HasPermission("other") {
+		t.Fatal("should not give the permissions of a privileged role to roles extending it")
+	}
+}
@@ -0,0 +1,17 @@
+package rbac
+
+import "context"
+
+// The context key of an authorizer.
+type authorizerKey struct{}
+
+// Returns a copy of the context carrying the authorizer, e.g. for middleware to pass it on to handlers.
+func ContextWithAuthorizer(ctx context.Context, a *Authorizer) context.Context {
+	return context.WithValue(ctx, authorizerKey{}, a)
+}
+
+// Returns the authorizer of the context and whether it has one.
+func AuthorizerFromContext(ctx context.Context) (*Authorizer, bool) {
+	a, ok := ctx.Value(authorizerKey{}).(*Authorizer)
+	return a, ok && a != nil
+}
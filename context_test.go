@@ -0,0 +1,19 @@
+package rbac_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_AuthorizerFromContext(t *testing.T) {
+	if _, ok := rbac.AuthorizerFromContext(context.Background()); ok {
+		t.Fatal("should not find an authorizer in an empty context")
+	}
+	ctx := rbac.ContextWithAuthorizer(context.Background(), Rbac.Authorizer("use.Account.Admin"))
+	az, ok := rbac.AuthorizerFromContext(ctx)
+	if !ok || !az.HasPermission("delete") {
+		t.Fatal("should return the authorizer of the context")
+	}
+}
@@ -0,0 +1,53 @@
+package rbac
+
+import "fmt"
+
+// Returns an option that gives every authorizer the default roles until a role is added, e.g. the
+// Unauthenticated role for anonymous requests. Combine with WithDefaultRolesAlwaysApply to keep them after a role
+// is added. NewRbacWithOptions returns an error if a default role does not exist.
+func WithDefaultRoles(roles ...string) Option {
+	return func(o *options) {
+		o.defaultRoles = append([]string{}, roles...)
+	}
+}
+
+// Returns an option that keeps the default roles of WithDefaultRoles after a role is added.
+func WithDefaultRolesAlwaysApply() Option {
+	return func(o *options) {
+		o.defaultRolesAlwaysApply = true
+	}
+}
+
+// Resolves and validates the default roles.
+func (r *Rbac) resolveDefaultRoles(roles []string) error {
+	r.defaultRoles = make([]string, 0, len(roles))
+	for _, role := range roles {
+		resolved := r.resolveRole(role)
+		if _, ok := r.roleToPermissionSet[resolved]; !ok {
+			if r.config.realm != "" {
+				// Default roles of other realms are not part of a realm view.
+				continue
+			}
+			return fmt.Errorf("default role %s does not exist", role)
+		}
+		r.defaultRoles = append(r.defaultRoles, resolved)
+	}
+	return nil
+}
+
+// Adds the default roles, marked so a later added role can suppress them.
+func (a *Authorizer) applyDefaults() {
+	for _, role := range a.rbac.defaultRoles {
+		a.roles.Store(role, false)
+	}
+}
+
+// Removes the default roles that were not added explicitly unless they always apply.
+func (a *Authorizer) suppressDefaults() {
+	if a.rbac.config.defaultRolesAlwaysApply {
+		return
+	}
+	for _, role := range a.rbac.defaultRoles {
+		a.roles.CompareAndDelete(role, false)
+	}
+}
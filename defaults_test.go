@@ -0,0 +1,46 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_WithDefaultRoles(t *testing.T) {
+	chains := func() []*rbac.RoleChain {
+		return []*rbac.RoleChain{
+			rbac.Chain("auth").Add("Unauthenticated", []string{"list"}).Add("Authenticated", []string{"create"}),
+			rbac.Chain("use.Account").Add("Member", []string{"get"}),
+		}
+	}
+	r, err := rbac.NewRbacWithOptions(chains(), rbac.WithDefaultRoles("auth.Unauthenticated"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Authorizer().HasPermission("list") {
+		t.Fatal("should apply the default roles without added roles")
+	}
+	az := r.Authorizer()
+	az.AddAsync(func() ([]string, error) {
+		return []string{"use.Account.Member"}, nil
+	})
+	if az.HasPermission("list") || !az.HasPermission("get") {
+		t.Fatal("should suppress the default roles once a role is added")
+	}
+	if !r.Authorizer("auth.Unauthenticated", "use.Account.Member").HasPermission("list") {
+		t.Fatal("should keep a default role that is added explicitly")
+	}
+
+	r, err = rbac.NewRbacWithOptions(chains(), rbac.WithDefaultRoles("auth.Unauthenticated"), rbac.WithDefaultRolesAlwaysApply())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Authorizer("use.Account.Member").HasPermission("list") {
+		t.Fatal("should keep the default roles when they always apply")
+	}
+
+	_, err = rbac.NewRbacWithOptions(chains(), rbac.WithDefaultRoles("auth.Guest"))
+	if err == nil || err.Error() != "default role auth.Guest does not exist" {
+		t.Fatalf("should reject unknown default roles, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package rbac
+
+import (
+	"slices"
+	"strings"
+)
+
+// Asynchronously adds permissions denied to this authorizer specifically, e.g. for a user under investigation
+// according to a remote source. Denies override grants: a denied permission is never given, not even by a super
+// admin, privileged or conditional role. Checks wait for async deny additions like for async role additions,
+// and an error or panic of f is reported by Err.
+func (a *Authorizer) AddDeniesAsync(f func() ([]string, error)) {
+	a.addAsync("deny", f, a.addDenies, nil)
+}
+
+// Adds permissions denied to this authorizer.
+func (a *Authorizer) addDenies(permissions []string) []string {
+	for _, permission := range permissions {
+		a.denies.Store(a.rbac.transformPermission(permission), true)
+	}
+	return nil
+}
+
+// Returns whether the transformed permission is denied to this authorizer, either directly or by a held role.
+func (a *Authorizer) isDenied(permission string) bool {
+	if _, ok := a.denies.Load(permission); ok {
+		return true
+	}
+	for role := range a.rbac.permissionToDenyRoleSet[permission] {
+		if _, ok := a.roles.Load(role); ok && a.rbac.roleEnabled(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the held enabled roles that deny the transformed permission, sorted.
+func (a *Authorizer) denyingRoles(permission string) []string {
+	roles := []string{}
+	for role := range a.rbac.permissionToDenyRoleSet[permission] {
+		if _, ok := a.roles.Load(role); ok && a.rbac.roleEnabled(role) {
+			roles = append(roles, role)
+		}
+	}
+	slices.Sort(roles)
+	return roles
+}
+
+// Returns the explanation of a deny by the roles, or of a deny of the authorizer itself if there are none.
+func denyReason(roles []string) string {
+	switch len(roles) {
+	case 0:
+		return "explicitly denied"
+	case 1:
+		return "explicitly denied by role " + roles[0]
+	}
+	return "explicitly denied by roles " + strings.Join(roles, ", ")
+}
+
+// Registers the permissions the role denies.
+func (r *Rbac) addDenyRole(role string, denies []string) {
+	for _, permission := range denies {
+		if r.permissionToDenyRoleSet[permission] == nil {
+			r.permissionToDenyRoleSet[permission] = map[string]bool{}
+		}
+		if r.roleToDenySet[role] == nil {
+			r.roleToDenySet[role] = map[string]bool{}
+		}
+		r.permissionToDenyRoleSet[permission][role] = true
+		r.roleToDenySet[role][permission] = true
+	}
+}
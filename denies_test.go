@@ -0,0 +1,151 @@
+package rbac_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_AddDeniesAsync(t *testing.T) {
+	az := Rbac.Authorizer("use.Account.Admin")
+	az.AddDeniesAsync(func() ([]string, error) {
+		return []string{"delete"}, nil
+	})
+	if az.HasPermission("delete") || !az.HasPermission("update") {
+		t.Fatal("should only deny the denied permissions")
+	}
+	if az.Err() != nil {
+		t.Fatal(az.Err())
+	}
+
+	r, err := rbac.NewRbac(rbac.Chain("ops").AddSuperAdmin("Root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := r.Authorizer("ops.Root")
+	root.AddDeniesAsync(func() ([]string, error) {
+		return []string{"delete"}, fmt.Errorf("partial denies")
+	})
+	root.AddDeniesAsync(func() ([]string, error) {
+		panic("boom")
+	})
+	if root.HasPermission("delete") {
+		t.Fatal("denies should override super admins")
+	}
+	if err := root.Err(); err == nil || !strings.Contains(err.Error(), "partial denies") || !strings.Contains(err.Error(), "async deny addition panicked: boom") {
+		t.Fatalf("should report deny loader errors, got %v", err)
+	}
+}
+
+func Test_AddDeny(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("use.Account").Add("Member", []string{"get", "create"}).Add("Admin", []string{"delete"}),
+		rbac.Chain("status").AddDeny("Suspended", []string{"create", "delete"}).AddDeny("Frozen", []string{"delete"}),
+		rbac.Chain("ops").AddSuperAdmin("Root"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	suspended := r.Authorizer("use.Account.Admin", "status.Suspended")
+	if suspended.HasPermission("create") || suspended.HasPermission("delete") {
+		t.Fatal("a deny of a held role should override a grant of another held role")
+	}
+	if !suspended.HasPermission("get") {
+		t.Fatal("should only deny the denied permissions")
+	}
+	if suspended.DenyReason("create") != "explicitly denied by role status.Suspended" {
+		t.Fatal("should report the deny role, got", suspended.DenyReason("create"))
+	}
+	frozen := r.Authorizer("use.Account.Admin", "status.Suspended", "status.Frozen")
+	if reason := frozen.DenyReason("delete"); reason != "explicitly denied by roles status.Frozen, status.Suspended" {
+		t.Fatal("should report all deny roles, got", reason)
+	}
+	if r.Authorizer("ops.Root", "status.Suspended").HasPermission("delete") {
+		t.Fatal("a deny should override a super admin")
+	}
+	if !r.Authorizer("use.Account.Admin").HasPermission("create") {
+		t.Fatal("a deny should only apply while its role is held")
+	}
+	if permissions := suspended.Permissions(); !reflect.DeepEqual(permissions, []string{"get"}) {
+		t.Fatal("should leave denied permissions out of the permissions, got", permissions)
+	}
+	if report := r.Report([]string{"use.Account.Admin", "status.Suspended"}); strings.Contains(report, "delete") {
+		t.Fatal("should leave denied permissions out of the report, got", report)
+	}
+	denied := r.Authorizer("use.Account.Member")
+	denied.AddDeniesAsync(func() ([]string, error) { return []string{"create"}, nil })
+	if permissions := denied.Permissions(); !reflect.DeepEqual(permissions, []string{"get"}) {
+		t.Fatal("should leave authorizer denies out of the permissions, got", permissions)
+	}
+
+	data, err := r.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := rbac.LoadRbac(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Equal(r) || loaded.Authorizer("use.Account.Member", "status.Suspended").HasPermission("create") {
+		t.Fatal("should keep denies in the config")
+	}
+	if _, err := rbac.NewRbac(rbac.Chain("status").AddDeny("Suspended", []string{""})); err == nil || err.Error() != "role status.Suspended has an empty permission" {
+		t.Fatal("should reject an empty deny, got", err)
+	}
+}
+
+func Test_AddDeny_Scoped(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("docs").Add("Viewer", []string{"read"}).Add("Editor", []string{"write"}),
+		rbac.Chain("status").AddDeny("Locked", []string{"write"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	az := r.Authorizer("docs.Editor")
+	az.AddScoped("doc-1", "status.Locked")
+	if az.HasPermissionOn("doc-1", "write") || !az.HasPermissionOn("doc-2", "write") || !az.HasPermissionOn("doc-1", "read") {
+		t.Fatal("a scoped deny role should only deny its permissions on the resource")
+	}
+	if !az.HasPermission("write") {
+		t.Fatal("a scoped deny role should not deny global checks")
+	}
+	if az.HasPermissionForAny("write", "doc-1") || !az.HasPermissionForAny("write", "doc-1", "doc-2") {
+		t.Fatal("a scoped deny role should deny its resource in HasPermissionForAny")
+	}
+	if permissions := az.PermissionsForResource("doc-1"); !reflect.DeepEqual(permissions, []string{"read"}) {
+		t.Fatal("should leave scoped denies out of the resource permissions, got", permissions)
+	}
+	scoped := r.Authorizer()
+	scoped.AddScoped("doc-1", "docs.Editor", "status.Locked")
+	if scoped.HasPermissionOn("doc-1", "write") || scoped.HasPermissionAnywhere("write") {
+		t.Fatal("a scoped deny role should override roles scoped to the same resource")
+	}
+	d := r.Evaluate(context.Background(), rbac.Request{Roles: []string{"docs.Editor"}, Resource: "doc-1", ResourceRoles: []string{"status.Locked"}, Permission: "write"})
+	if d.Allowed || d.Reason != "explicitly denied by role status.Locked" {
+		t.Fatalf("a scoped deny role should deny the evaluation of its resource, got %+v", d)
+	}
+}
+
+func Test_AddDeny_Copies(t *testing.T) {
+	denies := []string{"write"}
+	except := []string{"delete"}
+	chain := rbac.Chain("a").Add("Member", []string{"read", "write", "delete"}).AddDeny("Locked", denies)
+	ops := rbac.Chain("ops").AddAllExcept("Operator", except)
+	denies[0] = "read"
+	except[0] = "read"
+	r, err := rbac.NewRbac(chain, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Authorizer("a.Member", "a.Locked").HasPermission("write") || !r.Authorizer("a.Member", "a.Locked").HasPermission("read") {
+		t.Fatal("should copy the denies of a deny role")
+	}
+	if r.Authorizer("ops.Operator").HasPermission("delete") || !r.Authorizer("ops.Operator").HasPermission("read") {
+		t.Fatal("should copy the exclusions of an all-except role")
+	}
+}
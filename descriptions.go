@@ -0,0 +1,38 @@
+package rbac
+
+import "fmt"
+
+// Returns an option that registers what permissions do, e.g. "billing.void" voids an issued invoice, for security
+// reviews of exports like Report. Descriptions are metadata and do not affect checks. NewRbacWithOptions returns
+// an error if a described permission is not given by any role.
+func WithPermissionDescriptions(descriptions map[string]string) Option {
+	return func(o *options) {
+		o.permissionDescriptions = make(map[string]string, len(descriptions))
+		for permission, description := range descriptions {
+			o.permissionDescriptions[permission] = description
+		}
+	}
+}
+
+// Validates and registers the permission descriptions.
+func (r *Rbac) addPermissionDescriptions(descriptions map[string]string) error {
+	r.permissionToDescription = make(map[string]string, len(descriptions))
+	for _, permission := range sortedKeys(descriptions) {
+		transformed := r.transformPermission(permission)
+		if !r.isDefined(transformed) {
+			if r.config.realm != "" {
+				// Descriptions of permissions of other realms are not part of a realm view.
+				continue
+			}
+			return fmt.Errorf("description of unknown permission %s", permission)
+		}
+		r.permissionToDescription[transformed] = descriptions[permission]
+	}
+	return nil
+}
+
+// Returns the description of the permission registered WithPermissionDescriptions and whether it has one.
+func (r *Rbac) PermissionInfo(permission string) (description string, ok bool) {
+	description, ok = r.permissionToDescription[r.transformPermission(permission)]
+	return description, ok
+}
@@ -0,0 +1,41 @@
+package rbac_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_WithPermissionDescriptions(t *testing.T) {
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("billing").Add("Viewer", []string{"billing.read"}).Add("Admin", []string{"billing.void"}),
+	}, rbac.WithPermissionDescriptions(map[string]string{"billing.void": "Voids an issued invoice"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if description, ok := r.PermissionInfo("billing.void"); !ok || description != "Voids an issued invoice" {
+		t.Fatal("should return the description")
+	}
+	if _, ok := r.PermissionInfo("billing.read"); ok {
+		t.Fatal("should report a permission without description")
+	}
+	if report := r.Report([]string{"billing.Admin"}); !strings.Contains(report, "    billing.read\n    billing.void: Voids an issued invoice\n") {
+		t.Fatal("should include descriptions in the report, got", report)
+	}
+	c, err := r.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if description, _ := loaded.PermissionInfo("billing.void"); description != "Voids an issued invoice" {
+		t.Fatal("should round trip the descriptions through the config")
+	}
+	_, err = rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("billing").Add("Viewer", []string{"billing.read"})}, rbac.WithPermissionDescriptions(map[string]string{"billing.void": "Voids"}))
+	if err == nil || err.Error() != "description of unknown permission billing.void" {
+		t.Fatal("should reject descriptions of unknown permissions, got", err)
+	}
+}
@@ -0,0 +1,96 @@
+package rbac
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Returns a new role-based access controller made up of chains defined in environment variables:
+//
+//	{PREFIX}_CHAINS=auth,use.Account
+//	{PREFIX}_CHAIN_AUTH_DESCRIPTION=Whether the caller is signed in
+//	{PREFIX}_CHAIN_AUTH_ROLES=Unauthenticated,Authenticated
+//	{PREFIX}_CHAIN_AUTH_UNAUTHENTICATED_PERMS=list
+//	{PREFIX}_CHAIN_AUTH_AUTHENTICATED_PERMS=create
+//	{PREFIX}_CHAIN_USE_ACCOUNT_ROLES=Member,Admin
+//	...
+//	{PREFIX}_PERMISSION_CREATE_DESCRIPTION=Creates an account
+//
+// The chains variable lists the chain names in registration order and each chain's roles variable lists its
+// role ids in the order they extend each other. Each role's perms variable lists the permissions it adds to the
+// previous roles and must be set, even if empty. The optional description variables document a chain or a
+// permission. Chain names, role ids and permissions are upper cased in variable names with every character that is
+// not a letter or digit replaced by an underscore.
+func LoadEnv(prefix string) (*Rbac, error) {
+	chainNames, err := envList(prefix + "_CHAINS")
+	if err != nil {
+		return nil, err
+	}
+	c := Config{Chains: make([]ChainConfig, 0, len(chainNames))}
+	for _, chainName := range chainNames {
+		chainPrefix := prefix + "_CHAIN_" + envName(chainName)
+		roleIds, err := envList(chainPrefix + "_ROLES")
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: %w", chainName, err)
+		}
+		cc := ChainConfig{
+			Name:        chainName,
+			Description: strings.TrimSpace(os.Getenv(chainPrefix + "_DESCRIPTION")),
+			Roles:       make([]RoleConfig, 0, len(roleIds)),
+		}
+		for i, roleId := range roleIds {
+			permissions, err := envList(chainPrefix + "_" + envName(roleId) + "_PERMS")
+			if err != nil {
+				return nil, fmt.Errorf("role %s.%s: %w", chainName, roleId, err)
+			}
+			rc := RoleConfig{Id: roleId, Permissions: permissions}
+			if i > 0 {
+				rc.Extends = roleIds[i-1]
+			}
+			cc.Roles = append(cc.Roles, rc)
+		}
+		c.Chains = append(c.Chains, cc)
+	}
+	for _, cc := range c.Chains {
+		for _, rc := range cc.Roles {
+			for _, permission := range rc.Permissions {
+				description := strings.TrimSpace(os.Getenv(prefix + "_PERMISSION_" + envName(permission) + "_DESCRIPTION"))
+				if description == "" {
+					continue
+				}
+				if c.PermissionDescriptions == nil {
+					c.PermissionDescriptions = map[string]string{}
+				}
+				c.PermissionDescriptions[permission] = description
+			}
+		}
+	}
+	return c.Build()
+}
+
+// Returns the trimmed non-empty comma separated values of an environment variable, or an error if it is not set.
+func envList(key string) ([]string, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s not set", key)
+	}
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+// Returns the upper cased form of a chain name or role id used in environment variable names.
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name)
+}
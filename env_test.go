@@ -0,0 +1,46 @@
+package rbac_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_LoadEnv(t *testing.T) {
+	t.Setenv("RBAC_CHAINS", "auth, use.Account")
+	t.Setenv("RBAC_CHAIN_AUTH_ROLES", "Unauthenticated,Authenticated")
+	t.Setenv("RBAC_CHAIN_AUTH_UNAUTHENTICATED_PERMS", "list")
+	t.Setenv("RBAC_CHAIN_AUTH_AUTHENTICATED_PERMS", "create")
+	t.Setenv("RBAC_CHAIN_USE_ACCOUNT_ROLES", "Member,Admin")
+	t.Setenv("RBAC_CHAIN_USE_ACCOUNT_MEMBER_PERMS", "get")
+	t.Setenv("RBAC_CHAIN_USE_ACCOUNT_ADMIN_PERMS", "update,delete")
+	r, err := rbac.LoadEnv("RBAC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Authorizer("use.Account.Admin").HasPermission("get") {
+		t.Fatal("admin should extend member")
+	}
+	if r.Authorizer("auth.Unauthenticated").HasPermission("create") {
+		t.Fatal("unauthenticated should not have create permission")
+	}
+	t.Setenv("RBAC_CHAIN_AUTH_DESCRIPTION", "Whether the caller is signed in")
+	if r, _ := rbac.LoadEnv("RBAC"); r == nil {
+		t.Fatal("should load with a description")
+	} else if info, _ := r.ChainInfo("auth"); info.Description != "Whether the caller is signed in" {
+		t.Fatalf("should load the chain description, got %q", info.Description)
+	}
+	t.Setenv("RBAC_PERMISSION_CREATE_DESCRIPTION", "Creates an account")
+	if r, _ := rbac.LoadEnv("RBAC"); r == nil {
+		t.Fatal("should load with a permission description")
+	} else if description, _ := r.PermissionInfo("create"); description != "Creates an account" {
+		t.Fatalf("should load the permission description, got %q", description)
+	}
+
+	t.Setenv("RBAC_CHAIN_USE_ACCOUNT_ROLES", "Member,Admin,Owner")
+	_, err = rbac.LoadEnv("RBAC")
+	if err == nil || !strings.Contains(err.Error(), "RBAC_CHAIN_USE_ACCOUNT_OWNER_PERMS") {
+		t.Fatalf("should report missing owner permissions, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package rbac
+
+// Returns whether both roles have the same id, permissions and kind, ignoring the order of permissions.
+// Gates are functions and not compared.
+func (r *Role) Equal(other *Role) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	return r.Id == other.Id &&
+		r.allExcept == other.allExcept &&
+		r.independent == other.independent &&
+		r.superAdmin == other.superAdmin &&
+		r.parent == other.parent &&
+		equalSets(setOf(r.Permissions), setOf(other.Permissions)) &&
+		equalSets(setOf(r.except), setOf(other.except)) &&
+		equalSets(setOf(r.deny), setOf(other.deny))
+}
+
+// Returns whether both chains have the same name, extended chain and realm, equal roles in the same order and the
+// same conditional permissions. Descriptions and condition functions are not compared.
+func (c *RoleChain) Equal(other *RoleChain) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	if c.name != other.name || c.extends != other.extends || c.realm != other.realm || len(c.roles) != len(other.roles) {
+		return false
+	}
+	for i, role := range c.roles {
+		if !role.Equal(other.roles[i]) {
+			return false
+		}
+	}
+	return equalSets(conditionalSet(c.conditions), conditionalSet(other.conditions))
+}
+
+// Returns whether both rbacs give and deny the same effective permissions per flattened role, ignoring how the
+// chains were composed.
+func (r *Rbac) Equal(other *Rbac) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	if len(r.roleToPermissionSet) != len(other.roleToPermissionSet) {
+		return false
+	}
+	for role := range r.roleToPermissionSet {
+		if _, ok := other.roleToPermissionSet[role]; !ok {
+			return false
+		}
+		if !equalSets(r.effectivePermissionSet(role), other.effectivePermissionSet(role)) ||
+			!equalSets(r.roleToDenySet[role], other.roleToDenySet[role]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns a set of the values.
+func setOf(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// Returns a set of the role ids and permissions of the conditions.
+func conditionalSet(conditions []*condition) map[string]bool {
+	set := make(map[string]bool, len(conditions))
+	for _, c := range conditions {
+		set[c.roleId+" "+c.permission] = true
+	}
+	return set
+}
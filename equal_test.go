@@ -0,0 +1,51 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_Equal(t *testing.T) {
+	a := rbac.Chain("use.Account").Add("Member", []string{"get", "list"}).Add("Admin", []string{"update", "delete"})
+	b := rbac.Chain("use.Account").Add("Member", []string{"list", "get"}).Add("Admin", []string{"delete", "update"})
+	if !a.Equal(b) {
+		t.Fatal("chains should be equal regardless of permission order")
+	}
+	if a.Equal(rbac.Chain("use.Account").Add("Member", []string{"get", "list"})) {
+		t.Fatal("chains with different roles should not be equal")
+	}
+	if !(&rbac.Role{Id: "Member", Permissions: []string{"get", "list"}}).Equal(&rbac.Role{Id: "Member", Permissions: []string{"list", "get"}}) {
+		t.Fatal("roles should be equal regardless of permission order")
+	}
+	if (&rbac.Role{Id: "Member"}).Equal(nil) {
+		t.Fatal("a role should not equal nil")
+	}
+
+	r1, err := rbac.NewRbac(rbac.Chain("auth").Add("Unauthenticated", []string{"list"}), a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := rbac.NewRbac(b, rbac.Chain("auth").Add("Unauthenticated", []string{"list"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r1.Equal(r2) {
+		t.Fatal("rbacs should be equal regardless of chain and permission order")
+	}
+	if r1.Equal(Rbac) {
+		t.Fatal("rbacs with different roles should not be equal")
+	}
+
+	eager, err := rbac.NewRbac(rbac.Chain("auth").Add("Member", []string{"list", "get"}).AddAllExcept("Admin", []string{"get"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lazy, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("auth").Add("Member", []string{"get", "list"}).AddAllExcept("Admin", []string{"get"})}, rbac.WithLazyExpansion())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eager.Equal(lazy) {
+		t.Fatal("should compare the effective permissions of lazily expanded roles")
+	}
+}
@@ -0,0 +1,110 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// A single authorization request for Rbac.Evaluate and Authorizer.Evaluate.
+type Request struct {
+	// The subject the roles belong to, e.g. a user id, for the decision only.
+	Subject string
+	// The global roles of the subject.
+	Roles []string
+	// Adds roles to the global roles if not nil.
+	Provider RoleProvider
+	// The permissions denied to the subject.
+	Denies []string
+	// The resource the check is for, empty for a global check.
+	Resource string
+	// The roles of the subject that only count for the resource.
+	ResourceRoles []string
+	// The attributes conditional permissions are evaluated against, nil to ignore conditional permissions.
+	Attrs map[string]any
+	// The permission to check if Policy is nil.
+	Permission string
+	// The policy to check instead of a single permission if not nil.
+	Policy Policy
+}
+
+// The outcome of Rbac.Evaluate and Authorizer.Evaluate.
+type Decision struct {
+	// Whether the request is allowed.
+	Allowed bool
+	// The subject of the request.
+	Subject string
+	// Why the request was denied, empty if it is allowed.
+	Reason string
+	// The checked permissions that were not given, in evaluation order.
+	Missing []string
+	// The error that denied the request, e.g. of the provider or an unknown role, nil if none.
+	Err error
+}
+
+// Returns the decision for the request, as the one evaluation every integration can call. A permission is given
+// if a global role gives it, or a resource role while a resource is set, or a conditional permission whose
+// conditions pass against the attributes while attributes are set. A denied permission is never given, and any
+// error, e.g. a failing provider, an unknown role or ctx expiring before the provider returns, denies the request.
+func (r *Rbac) Evaluate(ctx context.Context, req Request) Decision {
+	_, invalid := r.validRoles(append(append([]string{}, req.Roles...), req.ResourceRoles...))
+	if len(invalid) > 0 {
+		err := fmt.Errorf("%s", strings.Join(invalid, "; "))
+		return Decision{Subject: req.Subject, Missing: []string{}, Err: err, Reason: fmt.Sprintf("resolving roles: %v", err)}
+	}
+	a := r.Authorizer(req.Roles...).WithSubject(req.Subject)
+	if req.Provider != nil {
+		a.AddProvider(ctx, req.Provider)
+	}
+	a.addDenies(req.Denies)
+	if req.Resource != "" && len(req.ResourceRoles) > 0 {
+		a.AddScoped(req.Resource, req.ResourceRoles...)
+	}
+	return a.Evaluate(ctx, req)
+}
+
+// Returns the decision for the permission or policy, resource and attributes of the request against the roles
+// of the authorizer, for integrations that already hold one, e.g. the rbachttp middlewares. The subject, roles,
+// provider, denies and resource roles of the request are ignored as they belong to the authorizer.
+func (a *Authorizer) Evaluate(ctx context.Context, req Request) Decision {
+	d := Decision{Subject: a.Subject(), Missing: []string{}}
+	if err := a.waitCtx(ctx); err != nil {
+		d.Err = err
+		d.Reason = fmt.Sprintf("resolving roles: %v", err)
+		return d
+	}
+	if err := a.Err(); err != nil {
+		d.Err = err
+		d.Reason = fmt.Sprintf("resolving roles: %v", err)
+		return d
+	}
+	check := func(requested string) bool {
+		permission := a.rbac.transformPermission(requested)
+		given := !(req.Resource != "" && a.scopeDenies(req.Resource, permission)) &&
+			a.decide(ctx, permission, func(permission string) bool {
+				return (req.Resource != "" && a.scopeGives(req.Resource, permission)) ||
+					(req.Attrs != nil && a.conditionsGive(permission, req.Attrs))
+			})
+		if !given {
+			d.Missing = append(d.Missing, requested)
+		}
+		return given
+	}
+	if req.Policy != nil {
+		d.Allowed = req.Policy.Eval(check)
+		if !d.Allowed {
+			d.Reason = fmt.Sprintf("requires %s, missing [%s]", req.Policy, strings.Join(d.Missing, " "))
+		}
+		return d
+	}
+	d.Allowed = check(req.Permission)
+	if !d.Allowed {
+		d.Reason = a.DenyReason(req.Permission)
+		if req.Resource != "" {
+			if roles := a.scopeDenyingRoles(req.Resource, a.rbac.transformPermission(req.Permission)); len(roles) > 0 {
+				d.Reason = denyReason(roles)
+			}
+		}
+	}
+	return d
+}
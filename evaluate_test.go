@@ -0,0 +1,82 @@
+package rbac_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_Evaluate(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("auth").Add("Authenticated", []string{"list"}),
+		rbac.Chain("doc").Add("Viewer", []string{"view"}).Add("Editor", []string{"edit"}).AddConditional("Viewer", "comment", func(attrs map[string]any) bool {
+			return attrs["open"] == true
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		req     rbac.Request
+		allowed bool
+		reason  string
+	}{
+		{"global", rbac.Request{Roles: []string{"doc.Viewer"}, Permission: "view"}, true, ""},
+		{"missing", rbac.Request{Roles: []string{"auth.Authenticated"}, Permission: "edit"}, false, "no role grants it, needs one of: doc.Editor"},
+		{"resource", rbac.Request{Resource: "doc-1", ResourceRoles: []string{"doc.Editor"}, Permission: "edit"}, true, ""},
+		{"other resource", rbac.Request{ResourceRoles: []string{"doc.Editor"}, Permission: "edit"}, false, "no role grants it, needs one of: doc.Editor"},
+		{"deny", rbac.Request{Roles: []string{"doc.Editor"}, Denies: []string{"edit"}, Permission: "edit"}, false, "explicitly denied"},
+		{"conditional", rbac.Request{Roles: []string{"doc.Viewer"}, Attrs: map[string]any{"open": true}, Permission: "comment"}, true, ""},
+		{"policy", rbac.Request{Roles: []string{"doc.Viewer"}, Policy: rbac.AllOf(rbac.Perm("view"), rbac.AnyOf(rbac.Perm("edit"), rbac.Perm("list")))}, false, "requires (view AND (edit OR list)), missing [edit list]"},
+		{"provider", rbac.Request{Provider: rbac.RoleProviderFunc(func(ctx context.Context) ([]string, error) {
+			return []string{"doc.Editor"}, nil
+		}), Permission: "edit"}, true, ""},
+		{"provider error", rbac.Request{Roles: []string{"doc.Editor"}, Provider: rbac.RoleProviderFunc(func(ctx context.Context) ([]string, error) {
+			return nil, fmt.Errorf("unavailable")
+		}), Permission: "edit"}, false, "resolving roles: unavailable"},
+		{"unknown role", rbac.Request{Roles: []string{"doc.Editor", "doc.Owner"}, Permission: "edit"}, false, "resolving roles: role doc.Owner not allowed"},
+		{"unknown resource role", rbac.Request{Resource: "doc-1", ResourceRoles: []string{"doc.Owner"}, Permission: "edit"}, false, "resolving roles: role doc.Owner not allowed"},
+	}
+	for _, test := range tests {
+		d := r.Evaluate(ctx, test.req)
+		if d.Allowed != test.allowed || d.Reason != test.reason {
+			t.Fatalf("%s: expected %v %q, got %v %q", test.name, test.allowed, test.reason, d.Allowed, d.Reason)
+		}
+	}
+	d := r.Evaluate(ctx, rbac.Request{Subject: "alice", Roles: []string{"doc.Viewer"}, Policy: rbac.AllOf(rbac.Perm("edit"), rbac.Perm("list"))})
+	if d.Subject != "alice" || !reflect.DeepEqual(d.Missing, []string{"edit"}) {
+		t.Fatalf("should report the subject and missing permissions, got %+v", d)
+	}
+}
+
+func Test_AuthorizerEvaluate(t *testing.T) {
+	a := Rbac.Authorizer("use.Account.Member").WithSubject("alice")
+	a.AddScoped("acc-1", "use.Account.Admin")
+	if d := a.Evaluate(context.Background(), rbac.Request{Permission: "get"}); !d.Allowed || d.Subject != "alice" {
+		t.Fatalf("should allow a permission of the authorizer, got %+v", d)
+	}
+	if d := a.Evaluate(context.Background(), rbac.Request{Roles: []string{"use.Account.Admin"}, Permission: "delete"}); d.Allowed {
+		t.Fatal("should ignore the roles of the request")
+	}
+	if d := a.Evaluate(context.Background(), rbac.Request{Resource: "acc-1", Permission: "delete"}); !d.Allowed {
+		t.Fatalf("should allow a permission of a scoped role on its resource, got %+v", d)
+	}
+}
+
+func Test_EvaluateCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	d := Rbac.Evaluate(ctx, rbac.Request{Provider: rbac.RoleProviderFunc(func(ctx context.Context) ([]string, error) {
+		time.Sleep(100 * time.Millisecond)
+		return []string{"use.Account.Admin"}, nil
+	}), Permission: "delete"})
+	if d.Allowed || d.Err != context.DeadlineExceeded {
+		t.Fatalf("should deny when ctx expires, got %+v", d)
+	}
+}
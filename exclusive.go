@@ -0,0 +1,57 @@
+package rbac
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returns an option that declares groups of roles that must not be held together, e.g. for separation of duties
+// between an Auditor and an Editor. Authorizer.Err reports every group of which an authorizer holds more than one
+// role once its async role additions settled. NewRbacWithOptions returns an error if an exclusive role does not
+// exist.
+func WithExclusiveRoles(groups [][]string) Option {
+	return func(o *options) {
+		o.exclusiveRoles = make([][]string, 0, len(groups))
+		for _, group := range groups {
+			o.exclusiveRoles = append(o.exclusiveRoles, append([]string{}, group...))
+		}
+	}
+}
+
+// Resolves and validates the groups of exclusive roles.
+func (r *Rbac) resolveExclusiveRoles(groups [][]string) error {
+	r.exclusiveRoles = make([][]string, 0, len(groups))
+	for _, group := range groups {
+		resolved := make([]string, 0, len(group))
+		for _, role := range group {
+			resolvedRole := r.resolveRole(role)
+			if _, ok := r.roleToPermissionSet[resolvedRole]; !ok {
+				if r.config.realm != "" {
+					// Exclusive roles of other realms are not part of a realm view.
+					continue
+				}
+				return fmt.Errorf("exclusive role %s does not exist", role)
+			}
+			resolved = append(resolved, resolvedRole)
+		}
+		r.exclusiveRoles = append(r.exclusiveRoles, resolved)
+	}
+	return nil
+}
+
+// Returns an error message for every group of exclusive roles of which more than one role is held.
+func (a *Authorizer) exclusiveConflicts() []string {
+	conflicts := []string{}
+	for _, group := range a.rbac.exclusiveRoles {
+		held := []string{}
+		for _, role := range group {
+			if _, ok := a.roles.Load(role); ok {
+				held = append(held, role)
+			}
+		}
+		if len(held) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("roles %s are mutually exclusive", strings.Join(held, ", ")))
+		}
+	}
+	return conflicts
+}
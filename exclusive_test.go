@@ -0,0 +1,30 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_WithExclusiveRoles(t *testing.T) {
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("compliance").AddIndependent("Auditor", []string{"audit"}).AddIndependent("Editor", []string{"edit"}).AddIndependent("Approver", []string{"approve"}),
+	}, rbac.WithExclusiveRoles([][]string{{"compliance.Auditor", "compliance.Editor", "compliance.Approver"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Authorizer("compliance.Auditor").Err(); err != nil {
+		t.Fatal(err)
+	}
+	az := r.Authorizer("compliance.Auditor")
+	az.AddAsync(func() ([]string, error) {
+		return []string{"compliance.Editor"}, nil
+	})
+	if err := az.Err(); err == nil || err.Error() != "roles compliance.Auditor, compliance.Editor are mutually exclusive" {
+		t.Fatal("should report held exclusive roles after async additions, got", err)
+	}
+	_, err = rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("compliance").Add("Auditor", []string{"audit"})}, rbac.WithExclusiveRoles([][]string{{"compliance.Auditor", "compliance.Editor"}}))
+	if err == nil || err.Error() != "exclusive role compliance.Editor does not exist" {
+		t.Fatal("should reject unknown exclusive roles, got", err)
+	}
+}
@@ -0,0 +1,48 @@
+package rbac
+
+import "time"
+
+// Adds a role that lapses at expiresAt, e.g. temporarily elevated access. There is no background timer: the
+// role is removed by the first check that runs after expiresAt, which then calls the OnRoleExpired callback.
+// Adding the role with Add as well makes it permanent.
+func (a *Authorizer) AddExpiring(role string, expiresAt time.Time) {
+	role = a.rbac.resolveRole(role)
+	a.hasExpiring.Store(true)
+	a.roles.Store(role, true)
+	a.expiries.Store(role, expiresAt)
+	a.roleGeneration.Add(1)
+	a.suppressDefaults()
+}
+
+// Sets a callback for every expiring role that lapses, e.g. to emit an audit event. It is called lazily during
+// the check that detects the expiry, so it should be fast. A nil callback removes it.
+func (a *Authorizer) OnRoleExpired(f func(role string)) *Authorizer {
+	if f == nil {
+		a.onRoleExpired.Store(nil)
+		return a
+	}
+	a.onRoleExpired.Store(&f)
+	return a
+}
+
+// Removes the expiring roles that lapsed and calls the expiry callback for each of them.
+func (a *Authorizer) expireRoles() {
+	if !a.hasExpiring.Load() {
+		return
+	}
+	now := time.Now()
+	a.expiries.Range(func(key, value interface{}) bool {
+		if now.Before(value.(time.Time)) {
+			return true
+		}
+		if _, ok := a.expiries.LoadAndDelete(key); !ok {
+			return true
+		}
+		role := key.(string)
+		a.roles.Delete(role)
+		if f := a.onRoleExpired.Load(); f != nil {
+			(*f)(role)
+		}
+		return true
+	})
+}
@@ -0,0 +1,33 @@
+package rbac_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_AddExpiring(t *testing.T) {
+	expired := []string{}
+	az := Rbac.Authorizer("use.Account.Member").OnRoleExpired(func(role string) {
+		expired = append(expired, role)
+	})
+	az.AddExpiring("use.Account.Admin", time.Now().Add(time.Hour))
+	if !az.HasPermission("delete") {
+		t.Fatal("should have the expiring role before it expires")
+	}
+	az.AddExpiring("auth.Authenticated", time.Now().Add(-time.Second))
+	if az.HasRole("auth.Authenticated") || !az.HasPermission("delete") {
+		t.Fatal("should remove lapsed roles at the next check")
+	}
+	az.HasPermission("get")
+	if !reflect.DeepEqual(expired, []string{"auth.Authenticated"}) {
+		t.Fatalf("should call the callback once per lapsed role, got %v", expired)
+	}
+
+	az = Rbac.Authorizer()
+	az.AddExpiring("use.Account.Admin", time.Now().Add(-time.Second))
+	az.Add("use.Account.Admin")
+	if !az.HasRole("use.Account.Admin") {
+		t.Fatal("adding the role permanently should cancel the expiry")
+	}
+}
@@ -0,0 +1,54 @@
+package rbac
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Makes the roles of this chain inherit the top permissions of another chain, i.e. the permissions of its most
+// senior extending role including any chain it extends itself, e.g. an account member is at least authenticated.
+// The inherited permissions seed every role that extends the previous roles, so they accumulate along the chain
+// like the permissions of its first role. Independent and all-except roles are not seeded.
+// NewRbac returns an error if the chain does not exist or the extensions form a cycle.
+func (c *RoleChain) Extends(chain string) *RoleChain {
+	c.extends = chain
+	return c
+}
+
+// Returns the inherited permissions of every chain that extends another chain.
+func chainBases(roleChains []*RoleChain) (map[string][]string, error) {
+	nameToTop := map[string]map[string]bool{}
+	nameToExtends := map[string]string{}
+	for _, chain := range roleChains {
+		if _, ok := nameToTop[chain.name]; !ok {
+			nameToTop[chain.name] = map[string]bool{}
+		}
+		for _, permission := range chain.permissions {
+			nameToTop[chain.name][permission] = true
+		}
+		if chain.extends != "" && nameToExtends[chain.name] == "" {
+			nameToExtends[chain.name] = chain.extends
+		}
+	}
+	bases := map[string][]string{}
+	for _, name := range sortedKeys(nameToExtends) {
+		path := []string{name}
+		base := map[string]bool{}
+		for extended := nameToExtends[name]; extended != ""; extended = nameToExtends[extended] {
+			if _, ok := nameToTop[extended]; !ok {
+				return nil, fmt.Errorf("chain %s extends unknown chain %s", path[len(path)-1], extended)
+			}
+			for _, visited := range path {
+				if visited == extended {
+					return nil, fmt.Errorf("chain extension cycle %s -> %s", strings.Join(path, " -> "), extended)
+				}
+			}
+			path = append(path, extended)
+			for permission := range nameToTop[extended] {
+				base[permission] = true
+			}
+		}
+		bases[name] = sortedKeys(base)
+	}
+	return bases, nil
+}
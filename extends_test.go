@@ -0,0 +1,40 @@
+package rbac_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_Extends(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("auth").Add("Unauthenticated", []string{"list"}).Add("Authenticated", []string{"create"}),
+		rbac.Chain("use.Account").Extends("auth").Add("Member", []string{"get"}).AddIndependent("Auditor", []string{"audit"}).Add("Admin", []string{"update"}),
+		rbac.Chain("billing").Extends("use.Account").Add("Viewer", []string{"billing.read"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if permissions, _ := r.PermissionsForRole("use.Account.Member"); !reflect.DeepEqual(permissions, []string{"create", "get", "list"}) {
+		t.Fatalf("should inherit the top permissions of auth, got %v", permissions)
+	}
+	if permissions, _ := r.PermissionsForRole("use.Account.Auditor"); !reflect.DeepEqual(permissions, []string{"audit"}) {
+		t.Fatalf("should not seed independent roles, got %v", permissions)
+	}
+	if permissions, _ := r.PermissionsForRole("billing.Viewer"); !reflect.DeepEqual(permissions, []string{"billing.read", "create", "get", "list", "update"}) {
+		t.Fatalf("should inherit transitively, got %v", permissions)
+	}
+
+	_, err = rbac.NewRbac(
+		rbac.Chain("a").Extends("b").Add("Member", []string{"get"}),
+		rbac.Chain("b").Extends("a").Add("Member", []string{"list"}),
+	)
+	if err == nil || err.Error() != "chain extension cycle a -> b -> a" {
+		t.Fatalf("should reject cycles, got %v", err)
+	}
+	_, err = rbac.NewRbac(rbac.Chain("a").Extends("auth").Add("Member", []string{"get"}))
+	if err == nil || err.Error() != "chain a extends unknown chain auth" {
+		t.Fatalf("should reject unknown chains, got %v", err)
+	}
+}
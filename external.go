@@ -0,0 +1,35 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+)
+
+// Consults an external policy engine, e.g. OPA, about a permission the local roles do not give.
+type ExternalFallback func(ctx context.Context, subject string, roles []string, permission string) (bool, error)
+
+// Returns an opt-in option that asks f before any permission check denies a permission, e.g. HasPermission,
+// HasPermissionOn, MustHave, Satisfies or Evaluate, to migrate policies to an external engine incrementally. Local
+// grants, including scoped and conditional ones, never call f so the hot path stays local, but every local deny
+// pays the latency of f, so it should be fast or cache its answers. Only HasPermissionCtx and Evaluate pass their
+// context to f. Explicitly denied and empty permissions are denied without calling f. An error of f is a deny and
+// Err reports it.
+func WithExternalFallback(f ExternalFallback) Option {
+	return func(o *options) {
+		o.externalFallback = f
+	}
+}
+
+// Returns whether the external fallback of the rbac gives a permission the local roles do not give.
+func (a *Authorizer) externalGives(ctx context.Context, permission string) bool {
+	f := a.rbac.config.externalFallback
+	if f == nil || permission == "" || a.isDenied(permission) {
+		return false
+	}
+	granted, err := f(ctx, a.subject, a.sortedRoles(), permission)
+	if err != nil {
+		a.errors.Store(fmt.Sprintf("external fallback for %s: %v", permission, err), true)
+		return false
+	}
+	return granted
+}
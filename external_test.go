@@ -0,0 +1,87 @@
+package rbac_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_WithExternalFallback(t *testing.T) {
+	asked := []string{}
+	fallback := func(ctx context.Context, subject string, roles []string, permission string) (bool, error) {
+		asked = append(asked, permission)
+		if permission == "billing.fail" {
+			return false, errors.New("engine down")
+		}
+		return subject == "alice" && reflect.DeepEqual(roles, []string{"billing.Viewer"}) && permission == "billing.refund", nil
+	}
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("billing").Add("Viewer", []string{"billing.read"}),
+	}, rbac.WithExternalFallback(fallback))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := r.Authorizer("billing.Viewer").WithSubject("alice")
+	if !a.HasPermission("billing.read") || len(asked) != 0 {
+		t.Fatal("should not ask the fallback about local grants")
+	}
+	if !a.HasPermissionCtx(context.Background(), "billing.refund") || a.HasPermission("billing.delete") {
+		t.Fatal("should ask the fallback about local denies")
+	}
+	if a.HasPermission("billing.fail") || a.Err() == nil || !strings.Contains(a.Err().Error(), "engine down") {
+		t.Fatal("should deny and record an error of the fallback")
+	}
+	a.AddDeniesAsync(func() ([]string, error) { return []string{"billing.refund"}, nil })
+	asked = nil
+	if a.HasPermission("billing.refund") || len(asked) != 0 {
+		t.Fatal("should not ask the fallback about denied permissions")
+	}
+}
+
+func Test_WithExternalFallback_EveryCheck(t *testing.T) {
+	fallback := func(ctx context.Context, subject string, roles []string, permission string) (bool, error) {
+		return permission == "billing.refund" || permission == "billing.edit:any", nil
+	}
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("billing").Add("Viewer", []string{"billing.read"}).Add("Admin", []string{"billing.refund"}),
+	}, rbac.WithExternalFallback(fallback))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := r.Authorizer("billing.Viewer")
+	if given, _ := a.CheckPermissionDefined("billing.refund"); !given {
+		t.Fatal("should ask the fallback in CheckPermissionDefined")
+	}
+	if given, _ := a.HasPermissionWithin(time.Second, "billing.refund"); !given {
+		t.Fatal("should ask the fallback in HasPermissionWithin")
+	}
+	if !a.HasPermissionOrRole("billing.refund", "billing.Admin") || a.MustHave("billing.refund") != nil {
+		t.Fatal("should ask the fallback in HasPermissionOrRole and MustHave")
+	}
+	if !a.HasAllPermissions("billing.read", "billing.refund") || !a.HasAnyPermission("billing.delete", "billing.refund") {
+		t.Fatal("should ask the fallback in HasAllPermissions and HasAnyPermission")
+	}
+	if !a.HasPermissionOn("invoice-1", "billing.refund") || !a.HasPermissionForAny("billing.refund", "invoice-1") || !a.HasPermissionAnywhere("billing.refund") {
+		t.Fatal("should ask the fallback in the scoped checks")
+	}
+	if !a.HasPermissionWithAttrs("billing.refund", map[string]any{}) || !a.HasScopedPermission("billing.edit", "own") {
+		t.Fatal("should ask the fallback in the conditional and scope hierarchy checks")
+	}
+	if given, err := a.CheckPermission("billing.refund"); err != nil || !given || a.DenyReason("billing.refund") != "" {
+		t.Fatal("should ask the fallback in CheckPermission and DenyReason, got", err)
+	}
+	if !a.Satisfies(rbac.AllOf(rbac.Perm("billing.read"), rbac.Perm("billing.refund"))) {
+		t.Fatal("should ask the fallback in Satisfies")
+	}
+	if d := r.Evaluate(context.Background(), rbac.Request{Roles: []string{"billing.Viewer"}, Permission: "billing.refund"}); !d.Allowed {
+		t.Fatal("should ask the fallback in Evaluate, got", d.Reason)
+	}
+	if got := rbac.Filter(a, []string{"billing.read", "billing.refund", "billing.delete"}, func(p string) string { return p }); !reflect.DeepEqual(got, []string{"billing.read", "billing.refund"}) {
+		t.Fatal("should ask the fallback in Filter, got", got)
+	}
+}
@@ -0,0 +1,32 @@
+package rbac
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Adds a role like Add with the permissions listed in a file, one per line or comma separated:
+//
+//	# read access
+//	get
+//	list, export
+//
+// Blank lines and everything after a # are ignored. Returns an error without adding the role if the file
+// cannot be read.
+func (c *RoleChain) AddFromFile(id string, fsys fs.FS, path string) (*RoleChain, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("role %s.%s: %w", c.name, id, err)
+	}
+	permissions := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		for _, permission := range strings.Split(line, ",") {
+			if permission = strings.TrimSpace(permission); permission != "" {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return c.Add(id, permissions), nil
+}
@@ -0,0 +1,29 @@
+package rbac_test
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_AddFromFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"member.txt": {Data: []byte("# read access\nget\n\nlist, export # bulk\r\n")},
+	}
+	chain, err := rbac.Chain("use.Account").AddFromFile("Member", fsys, "member.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := rbac.NewRbac(chain.Add("Admin", []string{"update"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if permissions, _ := r.PermissionsForRole("use.Account.Admin"); !reflect.DeepEqual(permissions, []string{"export", "get", "list", "update"}) {
+		t.Fatalf("should read the permissions of the file, got %v", permissions)
+	}
+	if _, err := rbac.Chain("use.Account").AddFromFile("Member", fsys, "missing.txt"); err == nil {
+		t.Fatal("should return missing file errors")
+	}
+}
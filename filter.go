@@ -0,0 +1,28 @@
+package rbac
+
+// Returns the items whose permission the authorizer has like HasPermission, e.g. to drop the items of a listing
+// the caller may not see. Waits for async role additions once for all items. The order of the items is kept.
+func Filter[T any](a *Authorizer, items []T, permission func(T) string) []T {
+	a.wait()
+	filtered := []T{}
+	for _, item := range items {
+		if a.gives(a.rbac.transformPermission(permission(item))) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// Returns the items for whose resource the authorizer has the permission like HasPermissionOn, e.g. the documents
+// the caller may read. Waits for async role additions once for all items. The order of the items is kept.
+func FilterOn[T any](a *Authorizer, items []T, resource func(T) string, permission string) []T {
+	a.wait()
+	permission = a.rbac.transformPermission(permission)
+	filtered := []T{}
+	for _, item := range items {
+		if a.hasPermissionOn(resource(item), permission) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,35 @@
+package rbac_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_Filter(t *testing.T) {
+	type doc struct {
+		id         string
+		permission string
+	}
+	r, err := rbac.NewRbac(rbac.Chain("docs").Add("Reader", []string{"docs.read"}).Add("Admin", []string{"docs.secret"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := r.Authorizer()
+	a.AddAsync(func() ([]string, error) { return []string{"docs.Reader"}, nil })
+	docs := []doc{{"a", "docs.read"}, {"b", "docs.secret"}, {"c", "docs.read"}}
+	filtered := rbac.Filter(a, docs, func(d doc) string { return d.permission })
+	if !reflect.DeepEqual(filtered, []doc{docs[0], docs[2]}) {
+		t.Fatal("should keep the permitted items in order, got", filtered)
+	}
+
+	a.AddScoped("b", "docs.Admin")
+	filtered = rbac.FilterOn(a, docs, func(d doc) string { return d.id }, "docs.secret")
+	if !reflect.DeepEqual(filtered, []doc{docs[1]}) {
+		t.Fatal("should keep the items whose resource gives the permission, got", filtered)
+	}
+	if len(rbac.Filter(a, nil, func(d doc) string { return d.permission })) != 0 {
+		t.Fatal("should return no items for no items")
+	}
+}
@@ -0,0 +1,44 @@
+package rbac
+
+import "fmt"
+
+// The error of Authorizer.MustHave when the permission is not given.
+type ErrForbidden struct {
+	// The permission that was not given.
+	Permission string
+	// The subject of the authorizer, empty if none.
+	Subject string
+	// The sorted roles of the authorizer.
+	Roles []string
+}
+
+// Returns the message naming the permission and subject.
+func (e *ErrForbidden) Error() string {
+	if e.Subject == "" {
+		return fmt.Sprintf("forbidden: permission %s not given", e.Permission)
+	}
+	return fmt.Sprintf("forbidden: permission %s not given to %s", e.Permission, e.Subject)
+}
+
+// Returns 403, the http status code of a forbidden request, for http error mappers.
+func (e *ErrForbidden) StatusCode() int {
+	return 403
+}
+
+// Returns an *ErrForbidden if none of the roles give the specified permission after waiting for async role and
+// deny additions, nil otherwise, e.g. to return from handlers that propagate errors:
+//
+//	if err := az.MustHave("delete"); err != nil {
+//		return err
+//	}
+func (a *Authorizer) MustHave(permission string) error {
+	a.wait()
+	if a.gives(a.rbac.transformPermission(permission)) {
+		return nil
+	}
+	return &ErrForbidden{
+		Permission: permission,
+		Subject:    a.subject,
+		Roles:      a.sortedRoles(),
+	}
+}
@@ -0,0 +1,38 @@
+package rbac_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_MustHave(t *testing.T) {
+	az := Rbac.Authorizer("use.Account.Member").WithSubject("alice")
+	if err := az.MustHave("get"); err != nil {
+		t.Fatal(err)
+	}
+	err := fmt.Errorf("deleting account: %w", az.MustHave("delete"))
+	forbidden := &rbac.ErrForbidden{}
+	if !errors.As(err, &forbidden) || forbidden.Permission != "delete" || forbidden.Subject != "alice" || !reflect.DeepEqual(forbidden.Roles, []string{"use.Account.Member"}) {
+		t.Fatal("should return a forbidden error with context, got", err)
+	}
+	if err.Error() != "deleting account: forbidden: permission delete not given to alice" {
+		t.Fatal("should name the permission and subject, got", err)
+	}
+	var status interface{ StatusCode() int }
+	if !errors.As(err, &status) || status.StatusCode() != 403 {
+		t.Fatal("should map to a 403 status code")
+	}
+	az.AddDeniesAsync(func() ([]string, error) {
+		return []string{"get"}, nil
+	})
+	if err := az.MustHave("get"); err == nil || err.Error() != "forbidden: permission get not given to alice" {
+		t.Fatal("should wait for and respect denies, got", err)
+	}
+	if err := Rbac.Authorizer().MustHave("get"); err == nil || err.Error() != "forbidden: permission get not given" {
+		t.Fatal("should omit an empty subject, got", err)
+	}
+}
@@ -0,0 +1,200 @@
+package rbac
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Returns an option that records a digest of the internal state once NewRbacWithOptions is done and panics
+// whenever a later query observes changed state. This is a developer safety net to keep the immutability of
+// a built rbac honest, not meant for production as every query recomputes the digest.
+// Building with the rbacdebug tag enables it for every rbac.
+func WithMutationDetection() Option {
+	return func(o *options) {
+		o.mutationDetection = true
+	}
+}
+
+// Records the digest of the internal state if mutation detection is enabled.
+func (r *Rbac) freeze(o *options) {
+	if o.mutationDetection || rbacdebug {
+		r.frozenDigest = r.digest()
+	}
+}
+
+// Panics if mutation detection is enabled and the internal state changed since the rbac was built.
+func (r *Rbac) assertFrozen() {
+	if r != nil && r.frozenDigest != nil && string(r.digest()) != string(r.frozenDigest) {
+		panic("rbac: internal state mutated after NewRbac")
+	}
+}
+
+// Returns a digest of the internal role, permission and chain state.
+func (r *Rbac) digest() []byte {
+	h := sha256.New()
+	for _, chain := range r.chainNames {
+		fmt.Fprintf(h, "chain %q %v\n", chain, r.chainToRoleNames[chain])
+		fmt.Fprintf(h, "ids %v\n", sortedKeys(r.chainToRoleIdSet[chain]))
+	}
+	for _, role := range sortedKeys(r.roleToPermissionSet) {
+		fmt.Fprintf(h, "role %q %v %v\n", role, sortedKeys(r.roleToPermissionSet[role]), sortedKeys(r.roleToExceptSet[role]))
+	}
+	for _, permission := range sortedKeys(r.permissionToRoleSet) {
+		fmt.Fprintf(h, "permission %q %v\n", permission, sortedKeys(r.permissionToRoleSet[permission]))
+	}
+	for _, permission := range sortedKeys(r.permissionToDenyRoleSet) {
+		fmt.Fprintf(h, "deny %q %v\n", permission, sortedKeys(r.permissionToDenyRoleSet[permission]))
+	}
+	// The structures precomputed for the hot path are hashed as they are, as they could diverge from the maps.
+	for _, permission := range sortedKeys(r.permissionToRolesSorted) {
+		fmt.Fprintf(h, "granting %q %q\n", permission, r.permissionToRolesSorted[permission])
+	}
+	for _, role := range sortedKeys(r.roleToPermissionsSorted) {
+		fmt.Fprintf(h, "sorted %q %q\n", role, r.roleToPermissionsSorted[role])
+	}
+	fmt.Fprintf(h, "universe %q\n", r.permissionsSorted)
+	for _, role := range sortedKeys(r.roleToBits) {
+		fmt.Fprintf(h, "bits %q %x\n", role, r.roleToBits[role])
+	}
+	for _, role := range sortedKeys(r.roleToCoveredSet) {
+		fmt.Fprintf(h, "covered %q %q\n", role, sortedKeys(r.roleToCoveredSet[role]))
+	}
+	r.wildcards.digest(h, "")
+	return h.Sum(nil)
+}
+
+// Writes the roles of the node and its children to the digest, prefixed with the path to the node.
+func (n *wildcardNode) digest(w io.Writer, path string) {
+	if n == nil {
+		return
+	}
+	fmt.Fprintf(w, "wildcard %q %q %q\n", path, n.roles, n.rest)
+	for _, segment := range sortedKeys(n.children) {
+		n.children[segment].digest(w, path+wildcardSeparator+segment)
+	}
+	n.any.digest(w, path+wildcardSeparator+"*")
+}
+
+// Returns a hex encoded SHA-256 hash of the normalized definition, i.e. every role with its chain, realm and
+// effective permissions, the descriptions, and every option that affects decisions, like aliases, default,
+// privileged and exclusive roles, the matcher, wildcards and the permission transform. It does not depend on the
+// order of chains, roles or permissions, so equivalent definitions produce the same fingerprint, e.g. to verify
+// every instance of a fleet loaded the same config. Functions like gates, the transform or the external fallback
+// are hashed by the name of their code, a matcher whose value holds pointers only by its type, and the version is
+// not part of it.
+func (r *Rbac) Fingerprint() string {
+	h := sha256.New()
+	for _, role := range sortedKeys(r.roleToPermissionSet) {
+		fmt.Fprintf(h, "role %q %q\n", r.roleToChain[role], role)
+		fmt.Fprintf(h, "permissions %q\n", sortedKeys(r.effectivePermissionSet(role)))
+	}
+	if len(r.superAdminSet) > 0 {
+		fmt.Fprintf(h, "super admins %q\n", sortedKeys(r.superAdminSet))
+	}
+	for _, role := range sortedKeys(r.roleToGate) {
+		fmt.Fprintf(h, "gated %q %q\n", role, funcName(r.roleToGate[role]))
+	}
+	for _, permission := range sortedKeys(r.permissionToRoleConditions) {
+		fmt.Fprintf(h, "conditional %q %q\n", permission, sortedKeys(r.permissionToRoleConditions[permission]))
+	}
+	for _, permission := range sortedKeys(r.permissionToDenyRoleSet) {
+		fmt.Fprintf(h, "denied %q %q\n", permission, sortedKeys(r.permissionToDenyRoleSet[permission]))
+	}
+	for _, chain := range sortedKeys(r.chainToRoleIdSet) {
+		fmt.Fprintf(h, "chain %q %q %q\n", chain, r.chainToRealm[chain], r.chainToDescription[chain])
+	}
+	for _, permission := range sortedKeys(r.permissionToDescription) {
+		fmt.Fprintf(h, "description %q %q\n", permission, r.permissionToDescription[permission])
+	}
+	for _, alias := range sortedKeys(r.aliasToRole) {
+		fmt.Fprintf(h, "alias %q %q\n", alias, r.aliasToRole[alias])
+	}
+	exclusive := make([]string, len(r.exclusiveRoles))
+	for i, group := range r.exclusiveRoles {
+		exclusive[i] = fmt.Sprintf("%q", slices.Sorted(slices.Values(group)))
+	}
+	sort.Strings(exclusive)
+	o := r.config
+	fmt.Fprintf(h, "defaults %q %v\n", r.defaultRoles, o.defaultRolesAlwaysApply)
+	fmt.Fprintf(h, "privileged %q\n", slices.Sorted(slices.Values(o.privilegedRoles)))
+	fmt.Fprintf(h, "exclusive %v\n", exclusive)
+	fmt.Fprintf(h, "matcher %s\n", matcherIdentity(o.matcher))
+	fmt.Fprintf(h, "wildcards %v\n", o.wildcardPermissions)
+	fmt.Fprintf(h, "transform %q\n", funcName(o.permissionTransform))
+	fmt.Fprintf(h, "external %q\n", funcName(o.externalFallback))
+	fmt.Fprintf(h, "scopes %q\n", o.scopeHierarchy)
+	fmt.Fprintf(h, "separator %q\n", r.roleSeparator())
+	fmt.Fprintf(h, "realm %q\n", o.realm)
+	fmt.Fprintf(h, "strict %v %v\n", o.strictRoleAdditions, o.headerRoleErrors)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Returns the identity of the matcher Fingerprint hashes: its type and, following pointers, its value, or only
+// its type if the value holds addresses that differ between processes, e.g. pointers or functions. A function
+// matcher is identified by the name of its code.
+func matcherIdentity(m Matcher) string {
+	if m == nil {
+		return ""
+	}
+	v := reflect.ValueOf(m)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch {
+	case v.Kind() == reflect.Func:
+		return fmt.Sprintf("%T %s", m, funcName(v.Interface()))
+	case holdsAddresses(v.Type(), map[reflect.Type]bool{}):
+		return fmt.Sprintf("%T", m)
+	}
+	return fmt.Sprintf("%T %#v", m, v.Interface())
+}
+
+// Returns whether values of the type print addresses, i.e. contain pointers, functions, channels or interfaces.
+func holdsAddresses(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Interface:
+		return true
+	case reflect.Array, reflect.Slice:
+		return holdsAddresses(t.Elem(), seen)
+	case reflect.Map:
+		return holdsAddresses(t.Key(), seen) || holdsAddresses(t.Elem(), seen)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if holdsAddresses(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns the name of the code of the function, empty if it is nil.
+func funcName(f any) string {
+	v := reflect.ValueOf(f)
+	if v.IsNil() {
+		return ""
+	}
+	return runtime.FuncForPC(v.Pointer()).Name()
+}
+
+// Returns the version set WithVersion, empty by default. Together with BuiltAt and Fingerprint it tells which
+// revision of the model a service runs.
+func (r *Rbac) Version() string {
+	return r.config.version
+}
+
+// Returns when the rbac was built.
+func (r *Rbac) BuiltAt() time.Time {
+	return r.builtAt
+}
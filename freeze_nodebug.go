@@ -0,0 +1,6 @@
+//go:build !rbacdebug
+
+package rbac
+
+// Whether mutation detection is enabled for every rbac.
+const rbacdebug = false
@@ -0,0 +1,48 @@
+package rbac
+
+import "testing"
+
+func Test_WithMutationDetection(t *testing.T) {
+	r, err := NewRbacWithOptions([]*RoleChain{Chain("auth").Add("Member", []string{"get"})}, WithMutationDetection())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Authorizer("auth.Member").HasPermission("get") {
+		t.Fatal("should have get permission")
+	}
+
+	delete(r.roleToPermissionSet["auth.Member"], "get")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("should panic after mutation")
+		}
+	}()
+	r.AllRoles()
+}
+
+func Test_WithMutationDetection_HotPath(t *testing.T) {
+	mutations := map[string]func(r *Rbac){
+		"granting roles": func(r *Rbac) { r.permissionToRolesSorted["get"][0] = "auth.Other" },
+		"sorted":         func(r *Rbac) { r.roleToPermissionsSorted["auth.Member"][0] = "other" },
+		"universe":       func(r *Rbac) { r.permissionsSorted[0] = "other" },
+		"bits":           func(r *Rbac) { r.roleToBits["auth.Member"][0] = 0 },
+		"wildcards":      func(r *Rbac) { r.wildcards.children["account"].rest = nil },
+	}
+	for name, mutate := range mutations {
+		r, err := NewRbacWithOptions([]*RoleChain{
+			Chain("auth").Add("Member", []string{"get"}).AddIndependent("Owner", []string{"account.*"}),
+		}, WithMutationDetection(), WithWildcardPermissions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		mutate(r)
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("should panic after mutating the", name)
+				}
+			}()
+			r.AllRoles()
+		}()
+	}
+}
@@ -1,3 +1,8 @@
 module github.com/acudac-com/rbac-go
 
 go 1.24.0
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,35 @@
+package rbac
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returns an authorizer with the roles of a comma separated header value like
+// "auth.Authenticated, use.Account.Member", as forwarded by many gateways in an X-Roles header.
+// Unknown roles are dropped, or recorded as errors reported by Err if the rbac was built WithHeaderRoleErrors.
+// Unknown roles are never added either way.
+func (r *Rbac) AuthorizerFromHeader(value string) *Authorizer {
+	a := r.Authorizer()
+	for _, role := range strings.Split(value, ",") {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			continue
+		}
+		if _, ok := a.rbac.roleToPermissionSet[a.rbac.resolveRole(role)]; !ok {
+			if a.rbac.config.headerRoleErrors {
+				a.errors.Store(fmt.Sprintf("role %s not allowed", role), true)
+			}
+			continue
+		}
+		a.Add(role)
+	}
+	return a
+}
+
+// Returns an option that makes AuthorizerFromHeader record unknown roles as errors instead of dropping them.
+func WithHeaderRoleErrors() Option {
+	return func(o *options) {
+		o.headerRoleErrors = true
+	}
+}
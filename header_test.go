@@ -0,0 +1,32 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_AuthorizerFromHeader(t *testing.T) {
+	az := Rbac.AuthorizerFromHeader(" auth.Authenticated, use.Account.Member,,use.Account.Owner")
+	if err := az.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !az.HasRole("auth.Authenticated") || !az.HasPermission("get") {
+		t.Fatal("should have the trimmed header roles")
+	}
+	if az.HasRole("use.Account.Owner") {
+		t.Fatal("should drop unknown roles")
+	}
+
+	strict, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("auth").Add("Authenticated", []string{"create"})}, rbac.WithHeaderRoleErrors())
+	if err != nil {
+		t.Fatal(err)
+	}
+	az = strict.AuthorizerFromHeader("auth.Authenticated,auth.Admin")
+	if err := az.Err(); err == nil || err.Error() != "role auth.Admin not allowed" {
+		t.Fatalf("should report the unknown role, got %v", err)
+	}
+	if az.HasRole("auth.Admin") || !az.HasRole("auth.Authenticated") {
+		t.Fatal("should only add known roles")
+	}
+}
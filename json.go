@@ -0,0 +1,63 @@
+package rbac
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Returns the subject, roles and permissions of the authorizer as json after waiting for async role additions,
+// e.g. {"roles":["use.Account.Member"],"permissions":["get"],"subject":"user-1"}.
+func (a *Authorizer) MarshalJSON() ([]byte, error) {
+	a.wait()
+	return json.Marshal(struct {
+		Roles       []string `json:"roles"`
+		Permissions []string `json:"permissions"`
+		Subject     string   `json:"subject"`
+	}{
+		Roles:       a.sortedRoles(),
+		Permissions: a.sortedPermissions(),
+		Subject:     a.subject,
+	})
+}
+
+// Returns a json document listing the required permission of each operation sorted by operation id, e.g.
+// {"operations":[{"operationId":"getAccount","permission":"get"}]} for an OpenAPI x-permissions extension.
+// Returns an error if an operation requires a permission that no role gives.
+func (r *Rbac) GenerateSecuritySchema(ops map[string]string) ([]byte, error) {
+	type operation struct {
+		OperationId string `json:"operationId"`
+		Permission  string `json:"permission"`
+	}
+	operations := make([]operation, 0, len(ops))
+	for _, id := range sortedKeys(ops) {
+		if !r.isDefined(r.transformPermission(ops[id])) {
+			return nil, fmt.Errorf("operation %s requires unknown permission %q", id, ops[id])
+		}
+		operations = append(operations, operation{OperationId: id, Permission: ops[id]})
+	}
+	return json.Marshal(struct {
+		Operations []operation `json:"operations"`
+	}{operations})
+}
+
+// Returns the config of the rbac as json that LoadRbac reads back into an equal rbac, listing each chain with its
+// ordered roles and the permissions they add, e.g.
+// {"chains":[{"name":"use.Account","roles":[{"id":"Member","permissions":["get"]}]}]}.
+// See Rbac.Config for what the config covers.
+func (r *Rbac) MarshalJSON() ([]byte, error) {
+	c, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(c)
+}
+
+// Returns a new role-based access controller from the config of a json document as written by Rbac.MarshalJSON.
+// The config is built with Config.Build, which validates it like NewRbac.
+func LoadRbac(data []byte) (*Rbac, error) {
+	c := Config{}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("decoding json: %w", err)
+	}
+	return c.Build()
+}
@@ -0,0 +1,74 @@
+package rbac_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_AuthorizerMarshalJSON(t *testing.T) {
+	az := Rbac.Authorizer("use.Account.Admin").WithSubject("user-1")
+	az.AddAsync(func() ([]string, error) {
+		return []string{"auth.Unauthenticated"}, nil
+	})
+	data, err := json.Marshal(az)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"roles":["auth.Unauthenticated","use.Account.Admin"],"permissions":["delete","get","list","update"],"subject":"user-1"}`
+	if string(data) != want {
+		t.Fatalf("unexpected json %s", data)
+	}
+}
+
+func Test_GenerateSecuritySchema(t *testing.T) {
+	data, err := Rbac.GenerateSecuritySchema(map[string]string{
+		"updateAccount": "update",
+		"getAccount":    "get",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"operations":[{"operationId":"getAccount","permission":"get"},{"operationId":"updateAccount","permission":"update"}]}`
+	if string(data) != want {
+		t.Fatalf("unexpected json %s", data)
+	}
+	if _, err := Rbac.GenerateSecuritySchema(map[string]string{"getAccount": "gett"}); err == nil {
+		t.Fatal("should reject unknown permissions")
+	}
+}
+
+func Test_RbacMarshalJSON(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("use.Account").Add("Member", []string{"get"}).Add("Admin", []string{"update", "delete"}).AddSuperAdmin("Root"),
+		rbac.Chain("ops").AddAllExcept("Operator", []string{"delete"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `{"name":"use.Account","roles":[{"id":"Member","permissions":["get"]},{"id":"Admin","extends":"Member","permissions":["delete","update"]}`) {
+		t.Fatal("should list the raw permissions of the ordered roles, got", string(data))
+	}
+	loaded, err := rbac.LoadRbac(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Equal(r) || !loaded.Authorizer("use.Account.Admin").HasPermission("get") {
+		t.Fatal("should load an equal rbac")
+	}
+
+	_, err = rbac.LoadRbac([]byte(`{"chains":[{"name":"a","roles":[{"id":"R","permissions":["x"]},{"id":"R","permissions":["y"]}]}]}`))
+	_, want := rbac.NewRbac(rbac.Chain("a").AddIndependent("R", []string{"x"}).AddIndependent("R", []string{"y"}))
+	if err == nil || want == nil || err.Error() != want.Error() {
+		t.Fatal("should validate like NewRbac, got", err, "want", want)
+	}
+	if _, err := rbac.LoadRbac([]byte("{")); err == nil {
+		t.Fatal("should reject invalid json")
+	}
+}
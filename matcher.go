@@ -0,0 +1,148 @@
+package rbac
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Matches requested permissions against granted ones that are not exactly equal, e.g. wildcards.
+type Matcher interface {
+	// Returns whether the granted permission covers the requested one.
+	Match(granted, requested string) bool
+}
+
+// A matcher that only matches equal permissions, the same as having no matcher.
+type ExactMatcher struct{}
+
+// Returns whether the permissions are equal.
+func (ExactMatcher) Match(granted, requested string) bool {
+	return granted == requested
+}
+
+// A matcher where a * in a granted permission matches any sequence of characters, e.g. "billing.*" matches
+// "billing.invoices.read".
+type GlobMatcher struct{}
+
+// Returns whether the requested permission matches the granted glob.
+func (GlobMatcher) Match(granted, requested string) bool {
+	parts := strings.Split(granted, "*")
+	if len(parts) == 1 {
+		return granted == requested
+	}
+	if !strings.HasPrefix(requested, parts[0]) {
+		return false
+	}
+	requested = requested[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(requested, part)
+		if i < 0 {
+			return false
+		}
+		requested = requested[i+len(part):]
+	}
+	return strings.HasSuffix(requested, parts[len(parts)-1])
+}
+
+// A matcher where a granted permission covers every permission beneath it in a hierarchy, e.g. "billing" covers
+// "billing.invoices.read" with the default separator ".".
+type HierarchyMatcher struct {
+	// The separator of the hierarchy levels, "." if empty.
+	Separator string
+}
+
+// Returns whether the requested permission equals or is beneath the granted one.
+func (m HierarchyMatcher) Match(granted, requested string) bool {
+	separator := m.Separator
+	if separator == "" {
+		separator = "."
+	}
+	return granted == requested || strings.HasPrefix(requested, granted+separator)
+}
+
+// A matcher that compiles a granted permission once so matching it against many requested permissions is fast.
+// NewRbacWithOptions compiles every granted permission of the model with it.
+type CompilingMatcher interface {
+	Matcher
+	// Returns a function reporting whether the granted permission covers a requested one.
+	Compile(granted string) func(requested string) bool
+}
+
+// Returns a function reporting whether the requested permission matches the granted glob.
+func (m GlobMatcher) Compile(granted string) func(requested string) bool {
+	if !strings.Contains(granted, "*") {
+		return func(requested string) bool { return granted == requested }
+	}
+	return func(requested string) bool { return m.Match(granted, requested) }
+}
+
+// A matcher where a granted permission is a regular expression that must match the whole requested permission,
+// e.g. billing\.(invoices|payments)\.read. A granted permission that is not a valid expression only matches
+// itself. Use it with WithMatcherCache to not recompile the expressions on every build.
+type RegexMatcher struct{}
+
+// Returns whether the requested permission matches the granted expression, compiling it on every call.
+func (m RegexMatcher) Match(granted, requested string) bool {
+	return m.Compile(granted)(requested)
+}
+
+// Returns a function reporting whether the requested permission matches the granted expression.
+func (RegexMatcher) Compile(granted string) func(requested string) bool {
+	expression, err := regexp.Compile("^(?:" + granted + ")$")
+	if err != nil {
+		return func(requested string) bool { return granted == requested }
+	}
+	return expression.MatchString
+}
+
+// Returns an option that matches requested permissions with m when no role gives them exactly. The exact lookup
+// always runs first and stays fast, but a check that falls back to the matcher compares the requested permission
+// against every permission of every added role, so it is proportional to their number.
+func WithMatcher(m Matcher) Option {
+	return func(o *options) {
+		o.matcher = m
+	}
+}
+
+// Overrides the matcher of the rbac for the checks of this authorizer, e.g. to allow wildcards for an internal
+// service while external callers require exact matches with ExactMatcher. Without it the authorizer inherits the
+// matcher of the rbac. Set it before the authorizer is shared.
+func (a *Authorizer) WithMatcher(m Matcher) *Authorizer {
+	a.matcher = m
+	return a
+}
+
+// Returns whether the matcher matches the permission against a permission of an added role.
+func (a *Authorizer) matches(permission string) bool {
+	return len(a.matchingRoles(permission, true)) > 0
+}
+
+// Returns the added roles that give a permission the matcher matches against the requested one, stopping at the
+// first one if first is set.
+func (a *Authorizer) matchingRoles(permission string, first bool) []string {
+	matcher := a.rbac.config.matcher
+	if a.matcher != nil {
+		matcher = a.matcher
+	}
+	matching := []string{}
+	if matcher == nil {
+		return matching
+	}
+	match := matcher.Match
+	if a.matcher == nil {
+		match = a.rbac.match
+	}
+	a.roles.Range(func(key, value interface{}) bool {
+		role := key.(string)
+		if !a.rbac.roleEnabled(role) {
+			return true
+		}
+		for granted := range a.rbac.rolePermissionSet(role) {
+			if match(granted, permission) {
+				matching = append(matching, role)
+				return !first
+			}
+		}
+		return true
+	})
+	return matching
+}
@@ -0,0 +1,76 @@
+package rbac_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_Matchers(t *testing.T) {
+	tests := []struct {
+		matcher   rbac.Matcher
+		granted   string
+		requested string
+		want      bool
+	}{
+		{rbac.ExactMatcher{}, "billing.read", "billing.read", true},
+		{rbac.ExactMatcher{}, "billing.*", "billing.read", false},
+		{rbac.GlobMatcher{}, "billing.*", "billing.invoices.read", true},
+		{rbac.GlobMatcher{}, "*.read", "billing.read", true},
+		{rbac.GlobMatcher{}, "billing.*.read", "billing.invoices.read", true},
+		{rbac.GlobMatcher{}, "billing.*.read", "billing.invoices.write", false},
+		{rbac.GlobMatcher{}, "billing.*", "account.read", false},
+		{rbac.HierarchyMatcher{}, "billing", "billing.invoices.read", true},
+		{rbac.HierarchyMatcher{}, "billing", "billingx.read", false},
+		{rbac.HierarchyMatcher{Separator: "/"}, "billing", "billing/read", true},
+	}
+	for _, test := range tests {
+		if got := test.matcher.Match(test.granted, test.requested); got != test.want {
+			t.Fatalf("%T %q %q: expected %v", test.matcher, test.granted, test.requested, test.want)
+		}
+	}
+}
+
+func Test_WithMatcher(t *testing.T) {
+	chains := []*rbac.RoleChain{rbac.Chain("billing").Add("Viewer", []string{"billing.*.read"}).Add("Admin", []string{"billing.*"})}
+	r, err := rbac.NewRbacWithOptions(chains, rbac.WithMatcher(rbac.GlobMatcher{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewer := r.Authorizer("billing.Viewer")
+	if !viewer.HasPermission("billing.invoices.read") || viewer.HasPermission("billing.invoices.write") {
+		t.Fatal("should match globs")
+	}
+	if !viewer.HasPermission("billing.*.read") {
+		t.Fatal("should still match exactly")
+	}
+	if granting := r.Authorizer("billing.Viewer", "billing.Admin").WhoGrants("billing.invoices.write"); !reflect.DeepEqual(granting, []string{"billing.Admin"}) {
+		t.Fatalf("should report the matching roles, got %v", granting)
+	}
+
+	exact, err := rbac.NewRbac(rbac.Chain("billing").Add("Viewer", []string{"billing.*.read"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exact.Authorizer("billing.Viewer").HasPermission("billing.invoices.read") {
+		t.Fatal("should only match exactly without a matcher")
+	}
+}
+
+func Test_AuthorizerWithMatcher(t *testing.T) {
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("billing").Add("Admin", []string{"billing.*"})}, rbac.WithMatcher(rbac.GlobMatcher{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Authorizer("billing.Admin").HasPermission("billing.read") {
+		t.Fatal("should inherit the matcher of the rbac")
+	}
+	if r.Authorizer("billing.Admin").WithMatcher(rbac.ExactMatcher{}).HasPermission("billing.read") {
+		t.Fatal("should use the matcher of the authorizer")
+	}
+	exact, _ := rbac.NewRbac(rbac.Chain("billing").Add("Admin", []string{"billing"}))
+	if exact.Authorizer("billing.Admin").HasPermission("billing.read") || !exact.Authorizer("billing.Admin").WithMatcher(rbac.HierarchyMatcher{}).HasPermission("billing.read") {
+		t.Fatal("should allow a looser matcher than the rbac")
+	}
+}
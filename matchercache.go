@@ -0,0 +1,85 @@
+package rbac
+
+import (
+	"reflect"
+	"sync"
+)
+
+// A cache of compiled matchers keyed by matcher and granted permission. It is safe for concurrent use and meant to
+// be shared by the rebuilds of one model, e.g. the reloads of a MutableRbac. Builds with different matchers never
+// share compiled permissions, and a matcher that is not comparable is not cached.
+type MatcherCache struct {
+	// The compiled func(requested string) bool of each matcherCacheKey.
+	compiled sync.Map
+}
+
+// The key of a compiled granted permission in a MatcherCache.
+type matcherCacheKey struct {
+	// The matcher that compiled the permission.
+	matcher CompilingMatcher
+	// The granted permission.
+	granted string
+}
+
+// Returns an empty matcher cache.
+func NewMatcherCache() *MatcherCache {
+	return &MatcherCache{}
+}
+
+// Returns the number of cached compiled matchers.
+func (c *MatcherCache) Len() int {
+	n := 0
+	c.compiled.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// Returns the cached granted permission compiled by m, compiling and caching it if it is missing. Compiles without
+// caching if m is not comparable.
+func (c *MatcherCache) compile(m CompilingMatcher, granted string) func(requested string) bool {
+	if !reflect.ValueOf(m).Comparable() {
+		return m.Compile(granted)
+	}
+	key := matcherCacheKey{m, granted}
+	if value, ok := c.compiled.Load(key); ok {
+		return value.(func(requested string) bool)
+	}
+	value, _ := c.compiled.LoadOrStore(key, m.Compile(granted))
+	return value.(func(requested string) bool)
+}
+
+// Returns an option that reuses the matchers compiled by earlier builds with the same cache, so rebuilding a
+// mostly unchanged model with a CompilingMatcher like RegexMatcher only compiles new permissions. A MutableRbac
+// reuses the options and therefore the cache on every reload. Without it every build compiles all permissions.
+func WithMatcherCache(c *MatcherCache) Option {
+	return func(o *options) {
+		o.matcherCache = c
+	}
+}
+
+// Compiles every granted permission if the matcher is a CompilingMatcher.
+func (r *Rbac) compileMatchers() {
+	m, ok := r.config.matcher.(CompilingMatcher)
+	if !ok {
+		return
+	}
+	r.compiledMatchers = make(map[string]func(requested string) bool, len(r.permissionToRoleSet))
+	for granted := range r.permissionToRoleSet {
+		if r.config.matcherCache != nil {
+			r.compiledMatchers[granted] = r.config.matcherCache.compile(m, granted)
+		} else {
+			r.compiledMatchers[granted] = m.Compile(granted)
+		}
+	}
+}
+
+// Returns whether the matcher of the rbac matches the requested permission against the granted one, using the
+// compiled granted permission if there is one.
+func (r *Rbac) match(granted, requested string) bool {
+	if compiled, ok := r.compiledMatchers[granted]; ok {
+		return compiled(requested)
+	}
+	return r.config.matcher.Match(granted, requested)
+}
@@ -0,0 +1,76 @@
+package rbac_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_MatcherCache(t *testing.T) {
+	cache := rbac.NewMatcherCache()
+	chain := rbac.Chain("billing").AddIndependent("Reader", []string{`billing\.(invoices|payments)\.read`, "billing.(broken"})
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{chain}, rbac.WithMatcher(rbac.RegexMatcher{}), rbac.WithMatcherCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := rbac.NewMutableRbac(r)
+	a := m.Authorizer("billing.Reader")
+	if !a.HasPermission("billing.payments.read") || a.HasPermission("billing.payments.write") || a.HasPermission("xbilling.invoices.read") {
+		t.Fatal("should match the whole permission against the expressions")
+	}
+	if !a.HasPermission("billing.(broken") {
+		t.Fatal("should match an invalid expression exactly")
+	}
+	if cache.Len() != 2 {
+		t.Fatal("should cache every compiled permission, got", cache.Len())
+	}
+	chain.AddIndependent("Writer", []string{`billing\..*\.write`})
+	if err := m.ReplaceChains(chain); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 3 || !m.Authorizer("billing.Writer").HasPermission("billing.invoices.write") {
+		t.Fatal("should reuse the cache on reload and compile only new permissions")
+	}
+
+	glob, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{chain}, rbac.WithMatcher(rbac.GlobMatcher{}), rbac.WithMatcherCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 6 || glob.Authorizer("billing.Writer").HasPermission("billing.invoices.write") {
+		t.Fatal("should not share compiled permissions between matchers")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{chain}, rbac.WithMatcher(rbac.RegexMatcher{}), rbac.WithMatcherCache(cache)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkMatcherCache(b *testing.B) {
+	chain := rbac.Chain("api")
+	for i := 0; i < 2000; i++ {
+		chain.AddIndependent(fmt.Sprintf("Role%d", i), []string{fmt.Sprintf(`api\.resource%d\.(read|write|delete)`, i)})
+	}
+	for _, warm := range []bool{false, true} {
+		b.Run(fmt.Sprintf("warm=%v", warm), func(b *testing.B) {
+			cache := rbac.NewMatcherCache()
+			for i := 0; i < b.N; i++ {
+				if !warm {
+					cache = rbac.NewMatcherCache()
+				}
+				if _, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{chain}, rbac.WithMatcher(rbac.RegexMatcher{}), rbac.WithMatcherCache(cache)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
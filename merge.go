@@ -0,0 +1,129 @@
+package rbac
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Returns an authorizer whose roles are the union of the roles of the authorizers after waiting for their async
+// role additions, e.g. for a principal acting as a user and a service account at once. Scoped roles, denies and
+// errors are merged as well, and a role that expires in one authorizer but not in another stays permanent.
+// Returns an error if the authorizers do not all belong to the same rbac.
+func MergeAuthorizers(authorizers ...*Authorizer) (*Authorizer, error) {
+	if len(authorizers) == 0 {
+		return nil, fmt.Errorf("no authorizers provided")
+	}
+	r := authorizers[0].rbac
+	for i, a := range authorizers {
+		if a.rbac != r {
+			return nil, fmt.Errorf("authorizer %d belongs to a different rbac", i)
+		}
+	}
+	merged := &Authorizer{rbac: r}
+	permanent := map[string]bool{}
+	expiries := map[string]time.Time{}
+	for _, a := range authorizers {
+		a.wait()
+		a.roles.Range(func(key, _ interface{}) bool {
+			role := key.(string)
+			if value, ok := a.expiries.Load(role); ok {
+				if expiresAt := value.(time.Time); expiresAt.After(expiries[role]) {
+					expiries[role] = expiresAt
+				}
+			} else {
+				permanent[role] = true
+			}
+			return true
+		})
+		a.scopes.Range(func(resource, value interface{}) bool {
+			scope, _ := merged.scopes.LoadOrStore(resource, &sync.Map{})
+			value.(*sync.Map).Range(func(role, _ interface{}) bool {
+				scope.(*sync.Map).Store(role, true)
+				return true
+			})
+			return true
+		})
+		a.denies.Range(func(permission, _ interface{}) bool {
+			merged.denies.Store(permission, true)
+			return true
+		})
+		a.errors.Range(func(message, _ interface{}) bool {
+			merged.errors.Store(message, true)
+			return true
+		})
+	}
+	for role := range permanent {
+		merged.roles.Store(role, true)
+	}
+	for role, expiresAt := range expiries {
+		if !permanent[role] {
+			merged.hasExpiring.Store(true)
+			merged.roles.Store(role, true)
+			merged.expiries.Store(role, expiresAt)
+		}
+	}
+	return merged, nil
+}
+
+// Returns a new role-based access controller made up of the chains of all rbacs, e.g. the core roles of an app and
+// the roles of plugins that register their chains independently. The rbacs must be built with identical options
+// apart from the version, and the result is built like NewRbacWithOptions with the options of the first, so it
+// behaves as if all chains had been passed to it at once, except that identical chains are taken once. Different
+// chains with the same name are handled by the duplicate policy, so the default returns a DuplicateChainError.
+// Returns an error if the options differ, or with the default duplicate policy if two rbacs define the same
+// flattened role with different effective permissions or denies. The result shares no state with the inputs.
+func Merge(rbacs ...*Rbac) (*Rbac, error) {
+	if len(rbacs) == 0 {
+		return nil, fmt.Errorf("no rbacs provided")
+	}
+	roleToSource := map[string]int{}
+	chains := []*RoleChain{}
+	for i, r := range rbacs {
+		r.assertFrozen()
+		if !r.config.equal(rbacs[0].config) {
+			return nil, fmt.Errorf("rbac %d is built with different options than rbac 0", i)
+		}
+		if r.config.duplicatePolicy == DuplicateError {
+			for _, role := range sortedKeys(r.roleToPermissionSet) {
+				j, ok := roleToSource[role]
+				if !ok {
+					roleToSource[role] = i
+					continue
+				}
+				other := rbacs[j]
+				if !equalSets(r.effectivePermissionSet(role), other.effectivePermissionSet(role)) ||
+					!equalSets(r.roleToDenySet[role], other.roleToDenySet[role]) {
+					return nil, fmt.Errorf("role %s is defined differently by rbacs %d and %d", role, j, i)
+				}
+			}
+		}
+	next:
+		for _, chain := range r.chains {
+			for _, existing := range chains {
+				if existing.Equal(chain) {
+					continue next
+				}
+			}
+			chains = append(chains, chain.clone())
+		}
+	}
+	return NewRbacWithOptions(chains, rbacs[0].opts...)
+}
+
+// Returns a copy of the chain that shares no mutable state with it.
+func (c *RoleChain) clone() *RoleChain {
+	clone := *c
+	clone.permissions = append([]string{}, c.permissions...)
+	clone.roles = make([]*Role, len(c.roles))
+	for i, role := range c.roles {
+		copied := *role
+		copied.Permissions = append([]string{}, role.Permissions...)
+		copied.except = append([]string{}, role.except...)
+		copied.deny = append([]string{}, role.deny...)
+		clone.roles[i] = &copied
+	}
+	clone.conditions = append([]*condition{}, c.conditions...)
+	clone.routes = append([]*route{}, c.routes...)
+	return &clone
+}
@@ -0,0 +1,125 @@
+package rbac_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_MergeAuthorizers(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("user").Add("Viewer", []string{"doc.read"}).Add("Editor", []string{"doc.write"}),
+		rbac.Chain("service").Add("Deployer", []string{"app.deploy"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := r.Authorizer("user.Editor")
+	account := r.Authorizer()
+	account.AddAsync(func() ([]string, error) { return []string{"user.Viewer", "service.Deployer"}, nil })
+	merged, err := rbac.MergeAuthorizers(user, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(merged.Roles(), []string{"service.Deployer", "user.Editor", "user.Viewer"}) {
+		t.Fatal("should union overlapping and disjoint roles, got", merged.Roles())
+	}
+	if !merged.HasPermission("doc.write") || !merged.HasPermission("app.deploy") {
+		t.Fatal("should give the permissions of every authorizer")
+	}
+
+	expiring := r.Authorizer()
+	expiring.AddExpiring("service.Deployer", time.Now().Add(-time.Second))
+	merged, err = rbac.MergeAuthorizers(expiring, r.Authorizer("user.Viewer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.HasPermission("app.deploy") || !merged.HasPermission("doc.read") {
+		t.Fatal("should keep expiring roles expiring")
+	}
+
+	other, _ := rbac.NewRbac(rbac.Chain("user").Add("Viewer", []string{"doc.read"}))
+	if _, err := rbac.MergeAuthorizers(user, other.Authorizer()); err == nil {
+		t.Fatal("should reject authorizers of different rbacs")
+	}
+	if _, err := rbac.MergeAuthorizers(); err == nil {
+		t.Fatal("should reject no authorizers")
+	}
+}
+
+func Test_Merge(t *testing.T) {
+	core, err := rbac.NewRbac(
+		rbac.Chain("auth").Add("Member", []string{"list"}),
+		rbac.Chain("ops").AddAllExcept("Operator", []string{"delete"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plugin := rbac.Chain("plugin").Add("User", []string{"plugin.use"}).Add("Admin", []string{"delete"})
+	withPlugin, err := rbac.NewRbac(rbac.Chain("auth").Add("Member", []string{"list"}), plugin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, err := rbac.Merge(core, withPlugin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	single, err := rbac.NewRbac(
+		rbac.Chain("auth").Add("Member", []string{"list"}),
+		rbac.Chain("ops").AddAllExcept("Operator", []string{"delete"}),
+		rbac.Chain("plugin").Add("User", []string{"plugin.use"}).Add("Admin", []string{"delete"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !merged.Equal(single) || merged.Fingerprint() != single.Fingerprint() {
+		t.Fatal("should behave as if all chains were passed to NewRbac")
+	}
+	if !merged.Authorizer("ops.Operator").HasPermission("plugin.use") || merged.Authorizer("ops.Operator").HasPermission("delete") {
+		t.Fatal("should expand all-except roles against the merged permissions")
+	}
+
+	plugin.AddIndependent("Late", []string{"late"})
+	if merged.IsValidRole("plugin.Late") {
+		t.Fatal("should not share chains with the inputs")
+	}
+
+	conflicting, _ := rbac.NewRbac(rbac.Chain("auth").Add("Member", []string{"get"}))
+	if _, err := rbac.Merge(core, conflicting); err == nil || err.Error() != "role auth.Member is defined differently by rbacs 0 and 1" {
+		t.Fatal("should reject a role defined differently, got", err)
+	}
+	if _, err := rbac.Merge(); err == nil {
+		t.Fatal("should reject no rbacs")
+	}
+
+	a, _ := rbac.NewRbac(rbac.Chain("c").Add("A", []string{"a"}))
+	b, _ := rbac.NewRbac(rbac.Chain("c").Add("B", []string{"b"}))
+	var duplicate *rbac.DuplicateChainError
+	if _, err := rbac.Merge(a, b); !errors.As(err, &duplicate) || duplicate.Chain != "c" {
+		t.Fatal("should reject different chains with the same name like NewRbac, got", err)
+	}
+	unionA, _ := rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("c").Add("A", []string{"a"})}, rbac.WithDuplicatePolicy(rbac.DuplicateUnion))
+	unionB, _ := rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("c").Add("A", []string{"b"})}, rbac.WithDuplicatePolicy(rbac.DuplicateUnion))
+	union, err := rbac.Merge(unionA, unionB)
+	if err != nil || !union.Authorizer("c.A").HasPermission("a") || !union.Authorizer("c.A").HasPermission("b") {
+		t.Fatal("should combine chains with the duplicate policy of the rbacs, got", err)
+	}
+	wildcards, _ := rbac.NewRbacWithOptions([]*rbac.RoleChain{plugin}, rbac.WithWildcardPermissions())
+	if _, err := rbac.Merge(core, wildcards); err == nil || err.Error() != "rbac 1 is built with different options than rbac 0" {
+		t.Fatal("should reject rbacs built with different options, got", err)
+	}
+	upper := rbac.WithPermissionTransform(strings.ToUpper)
+	upperCore, _ := rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("auth").Add("Member", []string{"list"})}, upper)
+	upperPlugin, _ := rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("plugin").Add("User", []string{"use"})}, upper, rbac.WithVersion("2"))
+	if merged, err := rbac.Merge(upperCore, upperPlugin); err != nil || !merged.Authorizer("plugin.User").HasPermission("use") {
+		t.Fatal("should merge rbacs built with the same options, got", err)
+	}
+	lower, _ := rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("plugin").Add("User", []string{"use"})}, rbac.WithPermissionTransform(strings.ToLower))
+	if _, err := rbac.Merge(upperCore, lower); err == nil {
+		t.Fatal("should reject rbacs built with different transforms")
+	}
+}
@@ -0,0 +1,58 @@
+package rbac
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// A role-based access controller whose chains can be replaced at runtime, e.g. when the config is reloaded.
+type MutableRbac struct {
+	// The current rbac.
+	current atomic.Pointer[Rbac]
+	// Serializes reloads and callback registrations.
+	mu sync.Mutex
+	// The callbacks invoked after every successful reload in registration order.
+	onReload []func(old, new *Rbac)
+}
+
+// Returns a mutable role-based access controller starting out with the given rbac.
+func NewMutableRbac(r *Rbac) *MutableRbac {
+	m := &MutableRbac{}
+	m.current.Store(r)
+	return m
+}
+
+// Returns the current rbac. Checks against the returned rbac are unaffected by later reloads.
+func (m *MutableRbac) Rbac() *Rbac {
+	return m.current.Load()
+}
+
+// Returns an authorizer of the current rbac to add roles to.
+func (m *MutableRbac) Authorizer(roles ...string) *Authorizer {
+	return m.Rbac().Authorizer(roles...)
+}
+
+// Builds a new rbac from the chains with the options of the current rbac and atomically swaps it in.
+// The current rbac is kept if building fails. Reload callbacks run after the swap.
+func (m *MutableRbac) ReplaceChains(roleChains ...*RoleChain) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old := m.current.Load()
+	new, err := NewRbacWithOptions(roleChains, old.opts...)
+	if err != nil {
+		return err
+	}
+	m.current.Store(new)
+	for _, f := range m.onReload {
+		f(old, new)
+	}
+	return nil
+}
+
+// Registers a callback invoked with the old and new rbac after every successful ReplaceChains, so derived
+// data can be invalidated. Callbacks run in registration order after the new rbac is swapped in.
+func (m *MutableRbac) OnReload(f func(old, new *Rbac)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onReload = append(m.onReload, f)
+}
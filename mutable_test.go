@@ -0,0 +1,44 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_MutableRbac(t *testing.T) {
+	m := rbac.NewMutableRbac(Rbac)
+	calls := []string{}
+	m.OnReload(func(old, new *rbac.Rbac) {
+		if old != Rbac {
+			t.Fatal("should receive the old rbac")
+		}
+		if m.Rbac() != new {
+			t.Fatal("should have swapped in the new rbac before the callback")
+		}
+		calls = append(calls, "first")
+	})
+	m.OnReload(func(old, new *rbac.Rbac) {
+		calls = append(calls, "second")
+	})
+
+	if err := m.ReplaceChains(rbac.Chain("auth").Add("Authenticated", []string{"create"})); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "second" {
+		t.Fatalf("should invoke callbacks in registration order, got %v", calls)
+	}
+	if m.Authorizer("use.Account.Member").HasPermission("get") {
+		t.Fatal("should no longer have the replaced chains")
+	}
+	if !m.Authorizer("auth.Authenticated").HasPermission("create") {
+		t.Fatal("should have the new chains")
+	}
+
+	if err := m.ReplaceChains(); err == nil {
+		t.Fatal("should error without chains")
+	}
+	if len(calls) != 2 {
+		t.Fatal("should not invoke callbacks after a failed reload")
+	}
+}
@@ -0,0 +1,31 @@
+package rbac
+
+// Checks permissions within a namespace, e.g. Can("read") checks "billing:read" in the billing namespace.
+type ScopedChecker struct {
+	// The authorizer checks are delegated to.
+	authorizer *Authorizer
+	// The namespace prefixed to permissions.
+	namespace string
+	// The separator between the namespace and a permission.
+	separator string
+}
+
+// Returns a checker that prefixes every permission with the namespace and a ":" separator.
+func (a *Authorizer) InNamespace(ns string) *ScopedChecker {
+	return &ScopedChecker{
+		authorizer: a,
+		namespace:  ns,
+		separator:  ":",
+	}
+}
+
+// Sets the separator between the namespace and a permission.
+func (c *ScopedChecker) WithSeparator(separator string) *ScopedChecker {
+	c.separator = separator
+	return c
+}
+
+// Returns whether the authorizer gives the permission within the namespace.
+func (c *ScopedChecker) Can(permission string) bool {
+	return c.authorizer.HasPermission(c.namespace + c.separator + permission)
+}
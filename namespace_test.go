@@ -0,0 +1,22 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_InNamespace(t *testing.T) {
+	r, err := rbac.NewRbac(rbac.Chain("billing").Add("Viewer", []string{"billing:read", "account/read"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	az := r.Authorizer("billing.Viewer")
+	billing := az.InNamespace("billing")
+	if !billing.Can("read") || billing.Can("write") {
+		t.Fatal("should check permissions within the billing namespace")
+	}
+	if !az.InNamespace("account").WithSeparator("/").Can("read") {
+		t.Fatal("should use the configured separator")
+	}
+}
@@ -0,0 +1,151 @@
+package rbac
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// An option to configure a role-based access controller in NewRbacWithOptions.
+type Option func(*options)
+
+// The configuration of a role-based access controller.
+type options struct {
+	// Whether all-except roles are evaluated on demand instead of expanded at build time.
+	lazyExpansion bool
+	// How duplicate flattened role names are handled.
+	duplicatePolicy DuplicatePolicy
+	// Whether queries panic if the internal state changed after building.
+	mutationDetection bool
+	// Whether AuthorizerFromHeader records unknown roles as errors.
+	headerRoleErrors bool
+	// Validates every permission at build time if not nil.
+	permissionValidator func(permission string) error
+	// The role each legacy role name maps to.
+	roleAliases map[string]string
+	// The roles of authorizers until a role is added.
+	defaultRoles []string
+	// Whether the default roles are kept after a role is added.
+	defaultRolesAlwaysApply bool
+	// The roles that pass every permission check.
+	privilegedRoles []string
+	// Matches permissions no role gives exactly if not nil.
+	matcher Matcher
+	// Whether authorizers record unknown roles as errors instead of storing them.
+	strictRoleAdditions bool
+	// The opaque version of the model.
+	version string
+	// The realm the rbac is a view of, empty for the full model.
+	realm string
+	// Whether authorizers rule out permissions with a bloom filter.
+	bloomFilter bool
+	// The groups of roles that must not be held together.
+	exclusiveRoles [][]string
+	// The scopes of HasScopedPermission from the broadest to the narrowest, the default if nil.
+	scopeHierarchy []string
+	// Transforms every permission when registered and checked if not nil.
+	permissionTransform func(permission string) string
+	// What each permission does.
+	permissionDescriptions map[string]string
+	// Shares compiled matchers across builds if not nil.
+	matcherCache *MatcherCache
+	// Asked about permissions the local roles do not give if not nil.
+	externalFallback ExternalFallback
+	// Whether * segments of granted permissions are wildcards.
+	wildcardPermissions bool
+	// The separator of flattened role names, "." if nil.
+	roleSeparator *string
+}
+
+// Returns an option that stores only the exclusions of all-except roles instead of expanding them to every
+// permission in the model. Checks evaluate such roles on demand against the requested permission, which is
+// slightly slower but uses much less memory for models with many permissions. Results match eager expansion.
+func WithLazyExpansion() Option {
+	return func(o *options) {
+		o.lazyExpansion = true
+	}
+}
+
+// How NewRbacWithOptions handles chains with the same name and chains that define the same flattened role name
+// more than once.
+type DuplicatePolicy int
+
+const (
+	// Duplicate chains and roles are an error. This is the default.
+	DuplicateError DuplicatePolicy = iota
+	// The last definition of a duplicate role replaces the previous ones, including its position in its chain.
+	DuplicateLastWins
+	// A duplicate role gives the union of the permissions of all its definitions and keeps its first position.
+	// Be aware that this can silently widen access when two configs disagree about a role.
+	DuplicateUnion
+)
+
+// Returns an option that sets how duplicate flattened role names are handled, e.g. when chains of several
+// configs are combined. Defaults to DuplicateError for safety.
+func WithDuplicatePolicy(policy DuplicatePolicy) Option {
+	return func(o *options) {
+		o.duplicatePolicy = policy
+	}
+}
+
+// Returns an option that validates every permission of every role at build time, e.g. to enforce a naming
+// convention. NewRbacWithOptions returns the first validation error naming the offending role and permission.
+// Without it permissions are not validated.
+func WithPermissionValidator(validate func(permission string) error) Option {
+	return func(o *options) {
+		o.permissionValidator = validate
+	}
+}
+
+// Returns an option that makes authorizers record every unknown role added with Add, AddAsync or an async
+// provider as an error reported by Err instead of storing it. Without it unknown roles are stored and only async
+// role additions report them.
+func WithStrictRoleAdditions() Option {
+	return func(o *options) {
+		o.strictRoleAdditions = true
+	}
+}
+
+// Returns an option that sets an opaque version of the model reported by Rbac.Version, e.g. a config revision.
+// It has no effect on checks.
+func WithVersion(version string) Option {
+	return func(o *options) {
+		o.version = version
+	}
+}
+
+// Returns whether the options build the same rbac from the same chains, ignoring the version and the matcher
+// cache which do not affect checks. Functions are compared by their code, so closures of one function literal
+// capturing different values are considered equal.
+func (o *options) equal(other *options) bool {
+	return o.lazyExpansion == other.lazyExpansion &&
+		o.duplicatePolicy == other.duplicatePolicy &&
+		o.mutationDetection == other.mutationDetection &&
+		o.headerRoleErrors == other.headerRoleErrors &&
+		sameFunc(o.permissionValidator, other.permissionValidator) &&
+		maps.Equal(o.roleAliases, other.roleAliases) &&
+		slices.Equal(o.defaultRoles, other.defaultRoles) &&
+		o.defaultRolesAlwaysApply == other.defaultRolesAlwaysApply &&
+		slices.Equal(o.privilegedRoles, other.privilegedRoles) &&
+		reflect.DeepEqual(o.matcher, other.matcher) &&
+		o.strictRoleAdditions == other.strictRoleAdditions &&
+		o.realm == other.realm &&
+		o.bloomFilter == other.bloomFilter &&
+		slices.EqualFunc(o.exclusiveRoles, other.exclusiveRoles, slices.Equal[[]string]) &&
+		slices.Equal(o.scopeHierarchy, other.scopeHierarchy) &&
+		sameFunc(o.permissionTransform, other.permissionTransform) &&
+		maps.Equal(o.permissionDescriptions, other.permissionDescriptions) &&
+		sameFunc(o.externalFallback, other.externalFallback) &&
+		o.wildcardPermissions == other.wildcardPermissions &&
+		(o.roleSeparator == nil) == (other.roleSeparator == nil) &&
+		(o.roleSeparator == nil || *o.roleSeparator == *other.roleSeparator)
+}
+
+// Returns whether both functions are nil or have the same code.
+func sameFunc(a, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.IsNil() || vb.IsNil() {
+		return va.IsNil() && vb.IsNil()
+	}
+	return va.Pointer() == vb.Pointer()
+}
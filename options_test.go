@@ -0,0 +1,314 @@
+package rbac_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+// Returns chains with the given number of permissions and a few all-except roles.
+func allExceptChains(permissionCount int) []*rbac.RoleChain {
+	permissions := make([]string, permissionCount)
+	for i := range permissions {
+		permissions[i] = fmt.Sprintf("perm%d", i)
+	}
+	chains := []*rbac.RoleChain{rbac.Chain("all").Add("Member", permissions)}
+	for i := 0; i < 10; i++ {
+		chain := rbac.Chain(fmt.Sprintf("ops%d", i))
+		chain.Add("Viewer", []string{"view"})
+		chain.AddAllExcept("Operator", permissions[i:i+10])
+		chain.Add("Owner", []string{permissions[i]})
+		chains = append(chains, chain)
+	}
+	return chains
+}
+
+func Test_WithLazyExpansion(t *testing.T) {
+	eager, err := rbac.NewRbac(allExceptChains(100)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lazy, err := rbac.NewRbacWithOptions(allExceptChains(100), rbac.WithLazyExpansion())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, role := range eager.AllRoles() {
+		eagerAz, lazyAz := eager.Authorizer(role), lazy.Authorizer(role)
+		for _, permission := range append(eager.AllPermissions(), "unknown") {
+			if eagerAz.HasPermission(permission) != lazyAz.HasPermission(permission) {
+				t.Fatalf("%s should match eager mode for %s", role, permission)
+			}
+		}
+		eagerPermissions, _ := eager.PermissionsForRole(role)
+		lazyPermissions, _ := lazy.PermissionsForRole(role)
+		if !reflect.DeepEqual(eagerPermissions, lazyPermissions) {
+			t.Fatalf("%s should have the same permissions in both modes", role)
+		}
+	}
+	if !reflect.DeepEqual(eager.RolesWithPermission("perm50"), lazy.RolesWithPermission("perm50")) {
+		t.Fatal("roles with permission should match eager mode")
+	}
+}
+
+func BenchmarkNewRbac(b *testing.B) {
+	b.Run("eager", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rbac.NewRbac(allExceptChains(5000)...)
+		}
+	})
+	b.Run("lazy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rbac.NewRbacWithOptions(allExceptChains(5000), rbac.WithLazyExpansion())
+		}
+	})
+}
+
+func BenchmarkHasPermissionAllExcept(b *testing.B) {
+	eager, _ := rbac.NewRbac(allExceptChains(5000)...)
+	lazy, _ := rbac.NewRbacWithOptions(allExceptChains(5000), rbac.WithLazyExpansion())
+	b.Run("eager", func(b *testing.B) {
+		az := eager.Authorizer("ops0.Operator")
+		for i := 0; i < b.N; i++ {
+			az.HasPermission("perm4000")
+		}
+	})
+	b.Run("lazy", func(b *testing.B) {
+		az := lazy.Authorizer("ops0.Operator")
+		for i := 0; i < b.N; i++ {
+			az.HasPermission("perm4000")
+		}
+	})
+}
+
+func Test_WithDuplicatePolicy(t *testing.T) {
+	chains := func() []*rbac.RoleChain {
+		return []*rbac.RoleChain{
+			rbac.Chain("auth").Add("Member", []string{"get"}).Add("Admin", []string{"update"}),
+			rbac.Chain("auth").Add("Member", []string{"list"}),
+		}
+	}
+	_, err := rbac.NewRbacWithOptions(chains())
+	duplicate := &rbac.DuplicateChainError{}
+	if !errors.As(err, &duplicate) || duplicate.Chain != "auth" || err.Error() != "duplicate chain auth" {
+		t.Fatalf("should error by default, got %v", err)
+	}
+	_, err = rbac.NewRbac(rbac.Chain("a").Add("b.Member", []string{"get"}), rbac.Chain("a.b").Add("Member", []string{"list"}))
+	if err == nil || err.Error() != "duplicate role a.b.Member" {
+		t.Fatalf("should still reject duplicate flattened roles, got %v", err)
+	}
+
+	lastWins, err := rbac.NewRbacWithOptions(chains(), rbac.WithDuplicatePolicy(rbac.DuplicateLastWins))
+	if err != nil {
+		t.Fatal(err)
+	}
+	permissions, _ := lastWins.PermissionsForRole("auth.Member")
+	if !reflect.DeepEqual(permissions, []string{"list"}) {
+		t.Fatalf("last definition should win, got %v", permissions)
+	}
+	if !reflect.DeepEqual(lastWins.AllRoles(), []string{"auth.Admin", "auth.Member"}) {
+		t.Fatalf("should keep both roles, got %v", lastWins.AllRoles())
+	}
+	if !lastWins.ChainHasRoleId("auth", "Member") || !lastWins.ChainHasRoleId("auth", "Admin") {
+		t.Fatal("chain should still have both role ids")
+	}
+
+	union, err := rbac.NewRbacWithOptions(chains(), rbac.WithDuplicatePolicy(rbac.DuplicateUnion))
+	if err != nil {
+		t.Fatal(err)
+	}
+	permissions, _ = union.PermissionsForRole("auth.Member")
+	if !reflect.DeepEqual(permissions, []string{"get", "list"}) {
+		t.Fatalf("should give the union of both definitions, got %v", permissions)
+	}
+}
+
+func Test_WithDuplicatePolicy_LazyParity(t *testing.T) {
+	chains := func() []*rbac.RoleChain {
+		return []*rbac.RoleChain{
+			rbac.Chain("auth").AddIndependent("Member", []string{"get"}),
+			rbac.Chain("auth").AddIndependent("Member", []string{"list"}),
+			rbac.Chain("ops").AddAllExcept("Operator", []string{"delete"}),
+		}
+	}
+	eager, err := rbac.NewRbacWithOptions(chains(), rbac.WithDuplicatePolicy(rbac.DuplicateLastWins))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lazy, err := rbac.NewRbacWithOptions(chains(), rbac.WithDuplicatePolicy(rbac.DuplicateLastWins), rbac.WithLazyExpansion())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []*rbac.Rbac{eager, lazy} {
+		if got := r.EffectivePermissionsForRole("ops.Operator"); !reflect.DeepEqual(got, []string{"list"}) {
+			t.Fatal("should expand all-except roles only against surviving definitions, got", got)
+		}
+		if r.Authorizer("ops.Operator").HasPermission("get") || !reflect.DeepEqual(r.AllPermissions(), []string{"list"}) {
+			t.Fatal("should not know the permissions of shadowed definitions")
+		}
+	}
+}
+
+func Test_EffectivePermissionsForRole(t *testing.T) {
+	lazy, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("all").Add("Member", []string{"get", "update", "delete"}),
+		rbac.Chain("ops").AddAllExcept("Operator", []string{"delete"}),
+	}, rbac.WithLazyExpansion())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if effective := lazy.EffectivePermissionsForRole("ops.Operator"); !reflect.DeepEqual(effective, []string{"get", "update"}) {
+		t.Fatalf("should expand the all-except role, got %v", effective)
+	}
+	if effective := lazy.EffectivePermissionsForRole("ops.Unknown"); effective == nil || len(effective) != 0 {
+		t.Fatal("should be empty for an unknown role")
+	}
+}
+
+func Test_Fingerprint(t *testing.T) {
+	a, _ := rbac.NewRbac(
+		rbac.Chain("auth").Add("Member", []string{"get"}).Add("Admin", []string{"update", "delete"}),
+		rbac.Chain("billing").Add("Viewer", []string{"invoice"}),
+	)
+	b, _ := rbac.NewRbac(
+		rbac.Chain("billing").Add("Viewer", []string{"invoice"}),
+		rbac.Chain("auth").Add("Member", []string{"get"}).AddIndependent("Admin", []string{"delete", "update", "get"}),
+	)
+	c, _ := rbac.NewRbac(
+		rbac.Chain("auth").Add("Member", []string{"get"}).Add("Admin", []string{"update"}),
+		rbac.Chain("billing").Add("Viewer", []string{"invoice"}),
+	)
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Fatal("equivalent definitions should have the same fingerprint")
+	}
+	if a.Fingerprint() == c.Fingerprint() {
+		t.Fatal("different definitions should have different fingerprints")
+	}
+	if len(a.Fingerprint()) != 64 {
+		t.Fatal("should be a hex encoded sha256")
+	}
+	chains := func() []*rbac.RoleChain {
+		return []*rbac.RoleChain{rbac.Chain("auth").Add("Member", []string{"get"}).Add("Admin", []string{"update"})}
+	}
+	plain, _ := rbac.NewRbacWithOptions(chains(), rbac.WithVersion("1"))
+	for name, opt := range map[string]rbac.Option{
+		"aliases":    rbac.WithRoleAliases(map[string]string{"member": "auth.Member"}),
+		"defaults":   rbac.WithDefaultRoles("auth.Member"),
+		"privileged": rbac.WithPrivilegedRoles("auth.Member"),
+		"exclusive":  rbac.WithExclusiveRoles([][]string{{"auth.Member", "auth.Admin"}}),
+		"matcher":    rbac.WithMatcher(rbac.HierarchyMatcher{}),
+		"wildcards":  rbac.WithWildcardPermissions(),
+		"transform":  rbac.WithPermissionTransform(strings.ToUpper),
+		"separator":  rbac.WithRoleSeparator("/"),
+		"strict":     rbac.WithStrictRoleAdditions(),
+	} {
+		other, err := rbac.NewRbacWithOptions(chains(), opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other.Fingerprint() == plain.Fingerprint() {
+			t.Fatal("should hash the option", name)
+		}
+	}
+	versioned, _ := rbac.NewRbacWithOptions(chains(), rbac.WithVersion("2"))
+	if versioned.Fingerprint() != plain.Fingerprint() {
+		t.Fatal("should not hash the version")
+	}
+	fingerprint := func(m rbac.Matcher) string {
+		r, err := rbac.NewRbacWithOptions(chains(), rbac.WithMatcher(m))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r.Fingerprint()
+	}
+	if fingerprint(&rbac.HierarchyMatcher{}) != fingerprint(&rbac.HierarchyMatcher{}) {
+		t.Fatal("should hash the value of a pointer matcher, not its address")
+	}
+	if fingerprint(&rbac.HierarchyMatcher{}) == fingerprint(&rbac.HierarchyMatcher{Separator: "/"}) {
+		t.Fatal("should hash the value of a pointer matcher")
+	}
+	if fingerprint(patternMatcher{regexp.MustCompile("a")}) != fingerprint(patternMatcher{regexp.MustCompile("a")}) {
+		t.Fatal("should hash a matcher holding pointers by its type")
+	}
+}
+
+// A matcher whose value holds a pointer.
+type patternMatcher struct {
+	pattern *regexp.Regexp
+}
+
+// Returns whether the requested permission matches the pattern.
+func (m patternMatcher) Match(granted, requested string) bool {
+	return m.pattern.MatchString(requested)
+}
+
+func Test_WithPermissionValidator(t *testing.T) {
+	pattern := regexp.MustCompile(`^[a-z][a-z0-9_.:-]*$`)
+	validator := rbac.WithPermissionValidator(func(permission string) error {
+		if !pattern.MatchString(permission) {
+			return fmt.Errorf("must match %s", pattern)
+		}
+		return nil
+	})
+	if _, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("auth").Add("Member", []string{"account.read"})}, validator); err != nil {
+		t.Fatal(err)
+	}
+	_, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("auth").Add("Member", []string{"account.read", "Bad\tPerm"})}, validator)
+	if err == nil || err.Error() != `role auth.Member permission "Bad\tPerm": must match ^[a-z][a-z0-9_.:-]*$` {
+		t.Fatalf("should report the offending role and permission, got %v", err)
+	}
+}
+
+func Test_WithStrictRoleAdditions(t *testing.T) {
+	chains := []*rbac.RoleChain{rbac.Chain("auth").Add("Member", []string{"get"})}
+	r, err := rbac.NewRbacWithOptions(chains, rbac.WithStrictRoleAdditions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	az := r.Authorizer("auth.Member", "auth.Owner")
+	if err := az.Err(); err == nil || err.Error() != "role auth.Owner not allowed" {
+		t.Fatalf("should report the unknown role, got %v", err)
+	}
+	if az.HasRole("auth.Owner") || !az.HasRole("auth.Member") {
+		t.Fatal("should only store known roles")
+	}
+	scoped := r.Authorizer()
+	scoped.AddScoped("doc-1", "auth.Owner")
+	if err := scoped.Err(); err == nil || err.Error() != "role auth.Owner not allowed" {
+		t.Fatalf("should reject unknown scoped roles, got %v", err)
+	}
+	defaulted, err := rbac.NewRbacWithOptions(chains, rbac.WithStrictRoleAdditions(), rbac.WithDefaultRoles("auth.Member"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if az := defaulted.Authorizer("auth.Owner"); !az.HasPermission("get") {
+		t.Fatal("should keep the default roles when every added role is rejected")
+	}
+
+	permissive, err := rbac.NewRbacWithOptions(chains)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if az := permissive.Authorizer("auth.Owner"); az.Err() != nil || !az.HasRole("auth.Owner") {
+		t.Fatal("should store unknown roles by default")
+	}
+}
+
+func Test_WithVersion(t *testing.T) {
+	before := time.Now()
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("auth").Add("Member", []string{"get"})}, rbac.WithVersion("v42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Version() != "v42" || r.BuiltAt().Before(before) || r.BuiltAt().After(time.Now()) {
+		t.Fatalf("unexpected version %q built at %v", r.Version(), r.BuiltAt())
+	}
+	if Rbac.Version() != "" {
+		t.Fatal("version should be empty by default")
+	}
+}
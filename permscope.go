@@ -0,0 +1,39 @@
+package rbac
+
+import "slices"
+
+// The scope hierarchy of HasScopedPermission without WithScopeHierarchy.
+var defaultScopeHierarchy = []string{"any", "own"}
+
+// Returns an option that sets the scopes of HasScopedPermission from the broadest to the narrowest, e.g.
+// "any", "team", "own", where a permission with a scope implies it with every narrower scope. Defaults to
+// "any", "own".
+func WithScopeHierarchy(scopes ...string) Option {
+	return func(o *options) {
+		o.scopeHierarchy = append([]string{}, scopes...)
+	}
+}
+
+// Returns whether one of the roles give the action with the scope or a broader one after waiting for async role
+// additions, e.g. HasScopedPermission("edit", "own") passes with "edit:own" or "edit:any" while
+// HasScopedPermission("edit", "any") only passes with "edit:any". A scope outside the hierarchy only passes with
+// the exact permission.
+func (a *Authorizer) HasScopedPermission(action, scope string) bool {
+	a.wait()
+	hierarchy := a.rbac.config.scopeHierarchy
+	if hierarchy == nil {
+		hierarchy = defaultScopeHierarchy
+	}
+	if !slices.Contains(hierarchy, scope) {
+		return a.gives(a.rbac.transformPermission(action + ":" + scope))
+	}
+	for _, broader := range hierarchy {
+		if a.gives(a.rbac.transformPermission(action + ":" + broader)) {
+			return true
+		}
+		if broader == scope {
+			break
+		}
+	}
+	return false
+}
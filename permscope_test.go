@@ -0,0 +1,36 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_HasScopedPermission(t *testing.T) {
+	r, err := rbac.NewRbac(rbac.Chain("posts").Add("Author", []string{"edit:own"}).Add("Moderator", []string{"edit:any"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	author := r.Authorizer("posts.Author")
+	if !author.HasScopedPermission("edit", "own") || author.HasScopedPermission("edit", "any") {
+		t.Fatal("own should not imply any")
+	}
+	moderator := r.Authorizer("posts.Moderator")
+	if !moderator.HasScopedPermission("edit", "own") || !moderator.HasScopedPermission("edit", "any") {
+		t.Fatal("any should imply own")
+	}
+	if moderator.HasScopedPermission("edit", "team") || moderator.HasScopedPermission("delete", "own") {
+		t.Fatal("should only pass a scope outside the hierarchy with the exact permission")
+	}
+}
+
+func Test_WithScopeHierarchy(t *testing.T) {
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("posts").AddIndependent("Lead", []string{"edit:team"})}, rbac.WithScopeHierarchy("any", "team", "own"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	az := r.Authorizer("posts.Lead")
+	if !az.HasScopedPermission("edit", "own") || !az.HasScopedPermission("edit", "team") || az.HasScopedPermission("edit", "any") {
+		t.Fatal("should follow the configured hierarchy")
+	}
+}
@@ -0,0 +1,201 @@
+package rbac
+
+import "strings"
+
+// A policy expression over permissions, e.g. AllOf(AnyOf(Perm("delete"), Perm("admin")), Perm("audited")).
+type Policy interface {
+	// Returns whether the policy holds given a function reporting whether a permission is granted.
+	Eval(hasPermission func(permission string) bool) bool
+	// Returns a human-readable form of the policy.
+	String() string
+}
+
+// A policy that requires a single permission.
+type Perm string
+
+// Returns whether the permission is granted.
+func (p Perm) Eval(hasPermission func(permission string) bool) bool {
+	return hasPermission(string(p))
+}
+
+// Returns the permission.
+func (p Perm) String() string {
+	return string(p)
+}
+
+// A policy that holds if any of its policies hold.
+type anyOf []Policy
+
+// Returns a policy that holds if at least one of the policies holds.
+// Without policies it never holds.
+func AnyOf(policies ...Policy) Policy {
+	return anyOf(policies)
+}
+
+func (p anyOf) Eval(hasPermission func(permission string) bool) bool {
+	for _, policy := range p {
+		if policy.Eval(hasPermission) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p anyOf) String() string {
+	return joinPolicies(p, " OR ")
+}
+
+// A policy that holds if all of its policies hold.
+type allOf []Policy
+
+// Returns a policy that holds if all of the policies hold.
+// Without policies it always holds.
+func AllOf(policies ...Policy) Policy {
+	return allOf(policies)
+}
+
+func (p allOf) Eval(hasPermission func(permission string) bool) bool {
+	for _, policy := range p {
+		if !policy.Eval(hasPermission) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p allOf) String() string {
+	return joinPolicies(p, " AND ")
+}
+
+// A policy that holds if its policy does not.
+type not struct {
+	policy Policy
+}
+
+// Returns a policy that holds if the given policy does not.
+func Not(policy Policy) Policy {
+	return not{policy}
+}
+
+func (p not) Eval(hasPermission func(permission string) bool) bool {
+	return !p.policy.Eval(hasPermission)
+}
+
+func (p not) String() string {
+	return "NOT " + p.policy.String()
+}
+
+// Returns the policies joined by sep in parentheses.
+func joinPolicies(policies []Policy, sep string) string {
+	parts := make([]string, len(policies))
+	for i, policy := range policies {
+		parts[i] = policy.String()
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+// Returns whether the roles satisfy the policy.
+func (a *Authorizer) Satisfies(p Policy) bool {
+	a.wait()
+	return p.Eval(func(permission string) bool {
+		return a.gives(a.rbac.transformPermission(permission))
+	})
+}
+
+// A policy expression over roles, e.g. AnyRole(RoleName("support.Staff"), RoleName("use.Account.Admin")).
+type RolePolicy interface {
+	// Returns whether the policy holds given a function reporting whether a role was added.
+	Eval(hasRole func(role string) bool) bool
+	// Returns a human-readable form of the policy.
+	String() string
+}
+
+// A role policy that requires a single flattened role.
+type RoleName string
+
+// Returns whether the role was added.
+func (r RoleName) Eval(hasRole func(role string) bool) bool {
+	return hasRole(string(r))
+}
+
+// Returns the role.
+func (r RoleName) String() string {
+	return string(r)
+}
+
+// A role policy that holds if any of its policies hold.
+type anyRole []RolePolicy
+
+// Returns a role policy that holds if at least one of the policies holds.
+// Without policies it never holds.
+func AnyRole(policies ...RolePolicy) RolePolicy {
+	return anyRole(policies)
+}
+
+func (p anyRole) Eval(hasRole func(role string) bool) bool {
+	for _, policy := range p {
+		if policy.Eval(hasRole) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p anyRole) String() string {
+	return joinRolePolicies(p, " OR ")
+}
+
+// A role policy that holds if all of its policies hold.
+type allRoles []RolePolicy
+
+// Returns a role policy that holds if all of the policies hold.
+// Without policies it always holds.
+func AllRoles(policies ...RolePolicy) RolePolicy {
+	return allRoles(policies)
+}
+
+func (p allRoles) Eval(hasRole func(role string) bool) bool {
+	for _, policy := range p {
+		if !policy.Eval(hasRole) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p allRoles) String() string {
+	return joinRolePolicies(p, " AND ")
+}
+
+// A role policy that holds if its policy does not.
+type notRole struct {
+	policy RolePolicy
+}
+
+// Returns a role policy that holds if the given policy does not.
+func NotRole(policy RolePolicy) RolePolicy {
+	return notRole{policy}
+}
+
+func (p notRole) Eval(hasRole func(role string) bool) bool {
+	return !p.policy.Eval(hasRole)
+}
+
+func (p notRole) String() string {
+	return "NOT " + p.policy.String()
+}
+
+// Returns the role policies joined by sep in parentheses.
+func joinRolePolicies(policies []RolePolicy, sep string) string {
+	parts := make([]string, len(policies))
+	for i, policy := range policies {
+		parts[i] = policy.String()
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+// Returns whether the added roles satisfy the role policy.
+func (a *Authorizer) SatisfiesRoles(p RolePolicy) bool {
+	a.wait()
+	return p.Eval(a.hasRole)
+}
@@ -0,0 +1,74 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_Satisfies(t *testing.T) {
+	member := Rbac.Authorizer("use.Account.Member")
+	admin := Rbac.Authorizer("use.Account.Admin")
+
+	policy := rbac.AllOf(
+		rbac.AnyOf(rbac.Perm("delete"), rbac.Perm("update")),
+		rbac.Perm("get"),
+	)
+	if member.Satisfies(policy) {
+		t.Fatal("member should not satisfy policy")
+	}
+	if !admin.Satisfies(policy) {
+		t.Fatal("admin should satisfy policy")
+	}
+
+	nested := rbac.AnyOf(rbac.Not(rbac.Perm("get")), rbac.AllOf(rbac.Perm("get"), rbac.Not(rbac.Perm("delete"))))
+	if !member.Satisfies(nested) {
+		t.Fatal("member should satisfy nested policy")
+	}
+	if admin.Satisfies(nested) {
+		t.Fatal("admin should not satisfy nested policy")
+	}
+
+	if !member.Satisfies(rbac.AllOf()) || member.Satisfies(rbac.AnyOf()) {
+		t.Fatal("empty AllOf should hold and empty AnyOf should not")
+	}
+	if got := nested.String(); got != "(NOT get OR (get AND NOT delete))" {
+		t.Fatalf("unexpected policy string %s", got)
+	}
+}
+
+func Test_SatisfiesRoles(t *testing.T) {
+	staff := Rbac.Authorizer("auth.Authenticated")
+	admin := Rbac.Authorizer("auth.Authenticated", "use.Account.Admin")
+
+	policy := rbac.AllRoles(
+		rbac.RoleName("auth.Authenticated"),
+		rbac.AnyRole(rbac.RoleName("use.Account.Admin"), rbac.NotRole(rbac.RoleName("auth.Authenticated"))),
+	)
+	if staff.SatisfiesRoles(policy) {
+		t.Fatal("authenticated should not satisfy policy")
+	}
+	if !admin.SatisfiesRoles(policy) {
+		t.Fatal("admin should satisfy policy")
+	}
+	if !staff.SatisfiesRoles(rbac.AllRoles()) || staff.SatisfiesRoles(rbac.AnyRole()) {
+		t.Fatal("empty AllRoles should hold and empty AnyRole should not")
+	}
+	if got := policy.String(); got != "(auth.Authenticated AND (use.Account.Admin OR NOT auth.Authenticated))" {
+		t.Fatalf("unexpected policy string %s", got)
+	}
+}
+
+func Test_LacksRole(t *testing.T) {
+	banned := Rbac.Authorizer("auth.Authenticated")
+	banned.AddAsync(func() ([]string, error) {
+		return []string{"use.Account.Member"}, nil
+	})
+	if banned.LacksRole("use.Account.Member") || !banned.LacksRole("use.Account.Admin") {
+		t.Fatal("should only lack roles that were not added")
+	}
+	policy := rbac.AllRoles(rbac.RoleName("auth.Authenticated"), rbac.NotRole(rbac.RoleName("use.Account.Member")))
+	if banned.SatisfiesRoles(policy) || !Rbac.Authorizer("auth.Authenticated").SatisfiesRoles(policy) {
+		t.Fatal("should require A and forbid B")
+	}
+}
@@ -0,0 +1,41 @@
+package rbac
+
+import "fmt"
+
+// Returns an option that makes the roles privileged: an authorizer with any of them passes every HasPermission
+// check without a lookup, like a role added with RoleChain.AddSuperAdmin. This intentionally bypasses fine-grained
+// checks for those roles, e.g. to speed up requests of admins. NewRbacWithOptions returns an error if a privileged
+// role does not exist.
+func WithPrivilegedRoles(roles ...string) Option {
+	return func(o *options) {
+		o.privilegedRoles = append([]string{}, roles...)
+	}
+}
+
+// Resolves, validates and registers the privileged roles as super admins.
+func (r *Rbac) addPrivilegedRoles(roles []string) error {
+	for _, role := range roles {
+		resolved := r.resolveRole(role)
+		if _, ok := r.roleToPermissionSet[resolved]; !ok {
+			if r.config.realm != "" {
+				// Privileged roles of other realms are not part of a realm view.
+				continue
+			}
+			return fmt.Errorf("privileged role %s does not exist", role)
+		}
+		r.superAdminSet[resolved] = true
+	}
+	return nil
+}
+
+// Returns whether one of the roles is privileged or a super admin after waiting for async role additions,
+// in which case every permission check passes.
+func (a *Authorizer) IsPrivileged() bool {
+	a.wait()
+	for role := range a.rbac.superAdminSet {
+		if _, ok := a.roles.Load(role); ok && a.rbac.roleEnabled(role) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_WithPrivilegedRoles(t *testing.T) {
+	chains := func() []*rbac.RoleChain {
+		return []*rbac.RoleChain{
+			rbac.Chain("use.Account").Add("Member", []string{"get"}).Add("Admin", []string{"update"}),
+			rbac.Chain("ops").AddSuperAdmin("Root"),
+		}
+	}
+	r, err := rbac.NewRbacWithOptions(chains(), rbac.WithPrivilegedRoles("use.Account.Admin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := r.Authorizer("use.Account.Admin")
+	if !admin.IsPrivileged() || !admin.HasPermission("anything") {
+		t.Fatal("privileged roles should pass every check")
+	}
+	if member := r.Authorizer("use.Account.Member"); member.IsPrivileged() || member.HasPermission("update") {
+		t.Fatal("other roles should not be privileged")
+	}
+	if !r.Authorizer("ops.Root").IsPrivileged() {
+		t.Fatal("super admins should be privileged")
+	}
+	_, err = rbac.NewRbacWithOptions(chains(), rbac.WithPrivilegedRoles("use.Account.Owner"))
+	if err == nil || err.Error() != "privileged role use.Account.Owner does not exist" {
+		t.Fatalf("should reject unknown privileged roles, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// A source of roles, e.g. resolving the roles of a token or from a database.
+type RoleProvider interface {
+	// Returns the flattened role names to add.
+	Roles(ctx context.Context) ([]string, error)
+}
+
+// A function that implements RoleProvider.
+type RoleProviderFunc func(ctx context.Context) ([]string, error)
+
+// Returns the roles of the function.
+func (f RoleProviderFunc) Roles(ctx context.Context) ([]string, error) {
+	return f(ctx)
+}
+
+// Asynchronously adds the roles of the provider like AddAsync.
+func (a *Authorizer) AddProvider(ctx context.Context, p RoleProvider) {
+	a.AddAsync(func() ([]string, error) {
+		return p.Roles(ctx)
+	})
+}
+
+// Returns an authorizer that asynchronously adds the roles of the provider, i.e. Authorizer followed by AddProvider.
+func (r *Rbac) AuthorizerWithProvider(ctx context.Context, p RoleProvider) *Authorizer {
+	a := r.Authorizer()
+	a.AddProvider(ctx, p)
+	return a
+}
+
+// Returns a combined error of the provider error and every role it returns that does not exist, nil if none.
+// It invokes the provider once without adding its roles anywhere, e.g. to smoke test a new integration.
+func (r *Rbac) ValidateProvider(ctx context.Context, p RoleProvider) error {
+	roles, err := p.Roles(ctx)
+	errors := []string{}
+	if err != nil {
+		errors = append(errors, err.Error())
+	}
+	errors = append(errors, r.invalidRoleErrors(roles)...)
+	if len(errors) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(errors, "; "))
+}
+
+// Returns a combined error of every provider that fails ValidateProvider, naming each by its position, nil if all
+// pass. The providers are invoked once concurrently, e.g. in a readiness probe that confirms the identity
+// integrations work before serving traffic.
+func (r *Rbac) WarmProviders(ctx context.Context, providers ...RoleProvider) error {
+	errs := make([]error, len(providers))
+	wg := sync.WaitGroup{}
+	for i, p := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = r.ValidateProvider(ctx, p)
+		}()
+	}
+	wg.Wait()
+	errors := []string{}
+	for i, err := range errs {
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("provider %d: %v", i, err))
+		}
+	}
+	if len(errors) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(errors, "; "))
+}
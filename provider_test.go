@@ -0,0 +1,63 @@
+package rbac_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+type tokenKey struct{}
+
+func Test_AuthorizerWithProvider(t *testing.T) {
+	provider := rbac.RoleProviderFunc(func(ctx context.Context) ([]string, error) {
+		if ctx.Value(tokenKey{}) != "admin-token" {
+			return nil, fmt.Errorf("invalid token")
+		}
+		return []string{"use.Account.Admin"}, nil
+	})
+	az := Rbac.AuthorizerWithProvider(context.WithValue(context.Background(), tokenKey{}, "admin-token"), provider)
+	if !az.HasPermission("delete") || az.Err() != nil {
+		t.Fatal("should add the roles of the provider")
+	}
+	az = Rbac.AuthorizerWithProvider(context.Background(), provider)
+	if az.HasPermission("get") || az.Err() == nil {
+		t.Fatal("should report the provider error")
+	}
+}
+
+func Test_ValidateProvider(t *testing.T) {
+	valid := rbac.RoleProviderFunc(func(ctx context.Context) ([]string, error) {
+		return []string{"use.Account.Admin", "auth.Authenticated"}, nil
+	})
+	if err := Rbac.ValidateProvider(context.Background(), valid); err != nil {
+		t.Fatal(err)
+	}
+	invalid := rbac.RoleProviderFunc(func(ctx context.Context) ([]string, error) {
+		return []string{"use.Account.Owner", "auth.Authenticated"}, fmt.Errorf("partial result")
+	})
+	err := Rbac.ValidateProvider(context.Background(), invalid)
+	if err == nil || err.Error() != "partial result; role use.Account.Owner not allowed" {
+		t.Fatalf("should combine the provider error and invalid roles, got %v", err)
+	}
+}
+
+func Test_WarmProviders(t *testing.T) {
+	valid := rbac.RoleProviderFunc(func(ctx context.Context) ([]string, error) {
+		return []string{"use.Account.Admin"}, nil
+	})
+	failing := rbac.RoleProviderFunc(func(ctx context.Context) ([]string, error) {
+		return nil, fmt.Errorf("connection refused")
+	})
+	invalid := rbac.RoleProviderFunc(func(ctx context.Context) ([]string, error) {
+		return []string{"use.Account.Owner"}, nil
+	})
+	if err := Rbac.WarmProviders(context.Background(), valid, valid); err != nil {
+		t.Fatal(err)
+	}
+	err := Rbac.WarmProviders(context.Background(), failing, valid, invalid)
+	if err == nil || err.Error() != "provider 0: connection refused; provider 2: role use.Account.Owner not allowed" {
+		t.Fatalf("should aggregate the errors of all providers, got %v", err)
+	}
+}
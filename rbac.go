@@ -1,9 +1,16 @@
 package rbac
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/bits"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // A role that gives a list of permissions.
@@ -12,6 +19,20 @@ type Role struct {
 	Id string
 	// The list of permissions the role gives.
 	Permissions []string
+	// Whether the role gives every permission in the model except the excluded ones.
+	allExcept bool
+	// The permissions excluded from an all-except role.
+	except []string
+	// Whether the role neither extends nor is extended by the other roles in its chain.
+	independent bool
+	// The id of the role this role extends, empty if none.
+	parent string
+	// Returns whether the role currently counts in checks, nil if it always does.
+	gate func() bool
+	// Whether the role gives every permission, even ones no other role gives.
+	superAdmin bool
+	// The permissions the role denies even if another held role gives them. Roles extending it do not inherit them.
+	deny []string
 }
 
 // A chain of roles which extend each other's permissions.
@@ -19,6 +40,18 @@ type RoleChain struct {
 	name        string
 	roles       []*Role
 	permissions []string
+	// The conditional permissions of roles in the chain.
+	conditions []*condition
+	// The id of the last added role that later roles extend, empty if none.
+	last string
+	// What the chain represents, for documentation only.
+	description string
+	// The name of the chain whose top permissions this chain inherits, empty if none.
+	extends string
+	// The request routes of roles in the chain.
+	routes []*route
+	// The realm the chain belongs to, empty if none.
+	realm string
 }
 
 // Returns a new chain to add roles which extend each other's permissions.
@@ -29,14 +62,106 @@ func Chain(name string) *RoleChain {
 	}
 }
 
-// Adds a role that extends the permissions of all previously added roles in the chain.
+// Returns a chain made up of roles with explicit permission lists, e.g. loaded from a database.
+// This bypasses the auto-extension of Add, so every role gives exactly its own permissions and the caller owns
+// their contents. Roles added to the chain later with Add extend the last of the given roles.
+func ChainFromRoles(name string, roles []*Role) *RoleChain {
+	c := Chain(name)
+	for _, role := range roles {
+		c.roles = append(c.roles, &Role{
+			Id:          role.Id,
+			Permissions: append([]string{}, role.Permissions...),
+		})
+		c.permissions = append([]string{}, role.Permissions...)
+		c.last = role.Id
+	}
+	return c
+}
+
+// Adds a role that extends the permissions of all previously added roles in the chain, except independent ones.
 func (c *RoleChain) Add(id string, permissions []string) *RoleChain {
 	extendedPermissions := append(c.permissions, permissions...)
 	c.roles = append(c.roles, &Role{
 		Id:          id,
 		Permissions: extendedPermissions,
+		parent:      c.last,
 	})
 	c.permissions = extendedPermissions
+	c.last = id
+	return c
+}
+
+// Adds a role that gives exactly the given permissions. Unlike Add it does not extend the previously added
+// roles, and roles added after it do not extend it, so a chain can group standalone roles next to extending ones.
+func (c *RoleChain) AddIndependent(id string, permissions []string) *RoleChain {
+	c.roles = append(c.roles, &Role{
+		Id:          id,
+		Permissions: append([]string{}, permissions...),
+		independent: true,
+	})
+	return c
+}
+
+// Adds a role that gives every permission, including permissions no role gives and ones added to the model later.
+// It bypasses all permission checks, so use it sparingly. Like AddIndependent it does not extend the previously
+// added roles and roles added after it do not extend it. Introspection lists every known permission for it.
+func (c *RoleChain) AddSuperAdmin(id string) *RoleChain {
+	c.roles = append(c.roles, &Role{
+		Id:          id,
+		Permissions: []string{},
+		independent: true,
+		superAdmin:  true,
+	})
+	return c
+}
+
+// Adds a role that gives nothing and denies the permissions, e.g. a Suspended role that strips "create" from a
+// user regardless of their other roles. A permission denied by a held role is never given, not even by a super
+// admin, privileged or conditional role, which matches denies added with Authorizer.AddDeniesAsync. Like
+// AddIndependent it does not extend the previously added roles and roles added after it do not extend it.
+func (c *RoleChain) AddDeny(id string, denies []string) *RoleChain {
+	c.roles = append(c.roles, &Role{
+		Id:          id,
+		Permissions: []string{},
+		independent: true,
+		deny:        append([]string{}, denies...),
+	})
+	return c
+}
+
+// Adds a role that extends the previously added roles like Add, but only counts in checks while enabled returns
+// true, e.g. to gate experimental capabilities behind a feature flag without rebuilding the rbac. Since its
+// permissions depend on the flag, roles added after it do not extend it. enabled is called on every check
+// that considers the role, so it should be cheap, e.g. by reading a cached flag value.
+func (c *RoleChain) AddGated(id string, permissions []string, enabled func() bool) *RoleChain {
+	c.roles = append(c.roles, &Role{
+		Id:          id,
+		Permissions: append(append([]string{}, c.permissions...), permissions...),
+		parent:      c.last,
+		gate:        enabled,
+	})
+	return c
+}
+
+// Adds a role that gives every permission in the final model except the excluded ones, along with the
+// permissions of all previously added roles in the chain. Roles added after it extend it as usual.
+// The permissions are expanded in NewRbac, so the role covers any permission added to the model later on,
+// including permissions of other chains.
+func (c *RoleChain) AddAllExcept(id string, except []string) *RoleChain {
+	c.roles = append(c.roles, &Role{
+		Id:          id,
+		Permissions: c.permissions,
+		allExcept:   true,
+		except:      append([]string{}, except...),
+		parent:      c.last,
+	})
+	c.last = id
+	return c
+}
+
+// Sets a description of what the chain represents, for documentation only.
+func (c *RoleChain) Describe(description string) *RoleChain {
+	c.description = description
 	return c
 }
 
@@ -45,42 +170,870 @@ type Rbac struct {
 	permissionToRoleSet map[string]map[string]bool
 	chainToRoleIdSet    map[string]map[string]bool
 	roleToPermissionSet map[string]map[string]bool
+	// The chain names in registration order.
+	chainNames []string
+	// The flattened role names of each chain in the order they were added.
+	chainToRoleNames map[string][]string
+	// The description of each described chain.
+	chainToDescription map[string]string
+	// The permissions excluded by each all-except role that is expanded lazily.
+	roleToExceptSet map[string]map[string]bool
+	// The chain each flattened role belongs to.
+	roleToChain map[string]string
+	// The gate of each gated role.
+	roleToGate map[string]func() bool
+	// The super admin and privileged roles that give every permission.
+	superAdminSet map[string]bool
+	// The flattened role each role extends.
+	roleToParent map[string]string
+	// The roles that give each permission ordered by seniority, so checks evaluate them in a stable order.
+	permissionToRolesSorted map[string][]string
+	// The sorted permissions of each role that is not expanded lazily, precomputed for the getters.
+	roleToPermissionsSorted map[string][]string
+	// All permissions sorted, indexing the bits of the role bitsets.
+	permissionsSorted []string
+	// The effective permissions of each role as a bitset over permissionsSorted.
+	roleToBits map[string][]uint64
+	// The position of each flattened role within its chain.
+	roleToIndex map[string]int
+	// The registration position of each chain.
+	chainToIndex map[string]int
+	// The conditions of each conditional permission per role.
+	permissionToRoleConditions map[string]map[string][]func(attrs map[string]any) bool
+	// The sorted roles that give each permission, computed once on first use.
+	permissionRoleMap     map[string][]string
+	permissionRoleMapOnce sync.Once
+	// The digest of the internal state after building if mutation detection is enabled.
+	frozenDigest []byte
+	// The options the rbac was built with, reused when rebuilding it.
+	opts []Option
+	// The configuration resolved from the options.
+	config *options
+	// The role each alias resolves to.
+	aliasToRole map[string]string
+	// The resolved default roles of authorizers.
+	defaultRoles []string
+	// The non-fatal model smells found when building.
+	warnings []string
+	// The request routes ordered by precedence.
+	routes []*route
+	// When the rbac was built.
+	builtAt time.Time
+	// The realm of each chain assigned to one.
+	chainToRealm map[string]string
+	// The view of each realm.
+	realms map[string]*Rbac
+	// The resolved groups of roles that must not be held together.
+	exclusiveRoles [][]string
+	// What each described permission does.
+	permissionToDescription map[string]string
+	// The permission of the chains each transformed permission was transformed from, nil without a transform.
+	permissionToRaw map[string]string
+	// The compiled form of each granted permission if the matcher is a CompilingMatcher.
+	compiledMatchers map[string]func(requested string) bool
+	// The trie of the permissions with a * segment, nil if there are none.
+	wildcards *wildcardNode
+	// The roles giving a permission with a * segment.
+	wildcardRoleSet map[string]bool
+	// The effective permissions of each role giving a wildcard permission, including the known permissions it
+	// covers.
+	roleToCoveredSet map[string]map[string]bool
+	// The roles denying each permission.
+	permissionToDenyRoleSet map[string]map[string]bool
+	// The permissions each role denies.
+	roleToDenySet map[string]map[string]bool
+	// Copies of the chains the rbac was built from, for Merge.
+	chains []*RoleChain
+}
+
+// The error of NewRbac when several chains have the same name, unless a duplicate policy other than
+// DuplicateError combines them.
+type DuplicateChainError struct {
+	// The name of the duplicate chain.
+	Chain string
+}
+
+// Returns the message naming the duplicate chain.
+func (e *DuplicateChainError) Error() string {
+	return fmt.Sprintf("duplicate chain %s", e.Chain)
 }
 
 // Returns a new role-based access controller made up of the provided role chains.
 // The final list of roles are flattened in the format {chainName}.{roleId}.
 func NewRbac(roleChains ...*RoleChain) (*Rbac, error) {
+	return NewRbacWithOptions(roleChains)
+}
+
+// Returns a new role-based access controller made up of the provided role chains and configured by the options.
+// The final list of roles are flattened in the format {chainName}.{roleId}, or with the separator of
+// WithRoleSeparator. The error joins every problem of the chains, or of the options once the chains are valid,
+// so a single run reports all of them. Its Unwrap method returns the individual errors.
+func NewRbacWithOptions(roleChains []*RoleChain, opts ...Option) (*Rbac, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
 	if len(roleChains) == 0 {
 		return nil, fmt.Errorf("no role chains provided")
 	}
-	permissionToRoleSet := map[string]map[string]bool{}
-	chainToRoleIdSet := map[string]map[string]bool{}
-	roleToPermissionSet := map[string]map[string]bool{}
+	if err := o.validateRoleSeparator(); err != nil {
+		return nil, err
+	}
+	r := &Rbac{
+		permissionToRoleSet: map[string]map[string]bool{},
+		chainToRoleIdSet:    map[string]map[string]bool{},
+		roleToPermissionSet: map[string]map[string]bool{},
+		chainNames:          []string{},
+		chainToRoleNames:    map[string][]string{},
+		chainToDescription:  map[string]string{},
+		roleToExceptSet:     map[string]map[string]bool{},
+		roleToChain:         map[string]string{},
+		roleToParent:        map[string]string{},
+		roleToGate:          map[string]func() bool{},
+		superAdminSet:       map[string]bool{},
+		chainToRealm:        map[string]string{},
+		opts:                opts,
+		config:              o,
+
+		permissionToRoleConditions: map[string]map[string][]func(attrs map[string]any) bool{},
+		permissionToDenyRoleSet:    map[string]map[string]bool{},
+		roleToDenySet:              map[string]map[string]bool{},
+	}
+	errs := []error{}
+	if o.duplicatePolicy == DuplicateError {
+		nameSet := map[string]bool{}
+		for _, chain := range roleChains {
+			if nameSet[chain.name] {
+				errs = append(errs, &DuplicateChainError{Chain: chain.name})
+			}
+			nameSet[chain.name] = true
+		}
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+	}
+	bases, err := chainBases(roleChains)
+	if err != nil {
+		return nil, err
+	}
+	// The last definition of each flattened role, the only one that survives DuplicateLastWins.
+	lastDefinitions := map[string]*Role{}
 	for _, chain := range roleChains {
-		chainToRoleIdSet[chain.name] = map[string]bool{}
 		for _, role := range chain.roles {
-			chainToRoleIdSet[chain.name][role.Id] = true
-			roleName := chain.name + "." + role.Id
-			if _, ok := roleToPermissionSet[roleName]; ok {
-				return nil, fmt.Errorf("duplicate role %s", roleName)
+			lastDefinitions[r.flatten(chain.name, role.Id)] = role
+		}
+	}
+	universe := map[string]bool{}
+	for _, chain := range roleChains {
+		for _, role := range chain.roles {
+			if o.duplicatePolicy == DuplicateLastWins && lastDefinitions[r.flatten(chain.name, role.Id)] != role {
+				continue
 			}
-			roleToPermissionSet[roleName] = map[string]bool{}
 			for _, permission := range role.Permissions {
-				if _, ok := permissionToRoleSet[permission]; !ok {
-					permissionToRoleSet[permission] = map[string]bool{}
+				universe[r.transformPermission(permission)] = true
+			}
+		}
+	}
+	for _, chain := range roleChains {
+		// The permissions excluded by the latest all-except role in the chain, nil if none.
+		var except map[string]bool
+		if _, ok := r.chainToRoleIdSet[chain.name]; !ok {
+			r.chainNames = append(r.chainNames, chain.name)
+			r.chainToRoleIdSet[chain.name] = map[string]bool{}
+			r.chainToRoleNames[chain.name] = []string{}
+		}
+		if chain.description != "" {
+			r.chainToDescription[chain.name] = chain.description
+		}
+		if chain.realm != "" {
+			r.chainToRealm[chain.name] = chain.realm
+		}
+		for _, role := range chain.roles {
+			roleName := r.flatten(chain.name, role.Id)
+			if _, ok := r.roleToPermissionSet[roleName]; ok {
+				switch existing := r.roleToChain[roleName]; {
+				case o.duplicatePolicy != DuplicateError && existing != chain.name:
+					errs = append(errs, fmt.Errorf("role %s is ambiguous between chains %s and %s", roleName, existing, chain.name))
+					continue
+				case o.duplicatePolicy == DuplicateLastWins:
+					r.removeRole(roleName)
+				case o.duplicatePolicy == DuplicateUnion:
+				default:
+					errs = append(errs, fmt.Errorf("duplicate role %s", roleName))
+					continue
+				}
+			}
+			rolePermissions := r.transformDefined(role.Permissions)
+			roleExcept := r.transformDefined(role.except)
+			roleDeny := r.transformDefined(role.deny)
+			if role.allExcept {
+				except = map[string]bool{}
+				for _, permission := range roleExcept {
+					except[permission] = true
+				}
+			}
+			if slices.Contains(role.Permissions, "") || slices.Contains(role.except, "") ||
+				slices.Contains(rolePermissions, "") || slices.Contains(roleExcept, "") ||
+				slices.Contains(role.deny, "") || slices.Contains(roleDeny, "") {
+				errs = append(errs, fmt.Errorf("role %s has an empty permission", roleName))
+				continue
+			}
+			if o.permissionValidator != nil {
+				invalid := false
+				for _, permission := range append(append([]string{}, rolePermissions...), roleExcept...) {
+					if err := o.permissionValidator(permission); err != nil {
+						errs = append(errs, fmt.Errorf("role %s permission %q: %w", roleName, permission, err))
+						invalid = true
+					}
+				}
+				if invalid {
+					continue
+				}
+			}
+			permissions := rolePermissions
+			if !role.independent && !role.allExcept {
+				permissions = append(permissions, r.transformDefined(bases[chain.name])...)
+			}
+			var lazyExcept map[string]bool
+			if except != nil && !role.independent && o.lazyExpansion {
+				lazyExcept = except
+			} else if except != nil && !role.independent {
+				for permission := range universe {
+					if !except[permission] {
+						permissions = append(permissions, permission)
+					}
 				}
-				permissionToRoleSet[permission][roleName] = true
-				roleToPermissionSet[roleName][permission] = true
 			}
+			r.addRole(chain.name, role.Id, permissions, lazyExcept)
+			if role.parent != "" {
+				r.roleToParent[roleName] = r.flatten(chain.name, role.parent)
+			}
+			if role.gate != nil {
+				r.roleToGate[roleName] = role.gate
+			}
+			if role.superAdmin {
+				r.superAdminSet[roleName] = true
+			}
+			r.addDenyRole(roleName, roleDeny)
+		}
+		errs = append(errs, r.addConditions(chain)...)
+	}
+	if err := r.addRoutes(roleChains); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if err := r.resolveAliases(o.roleAliases); err != nil {
+		// Aliases are resolved first as the other options may refer to them.
+		return nil, err
+	}
+	for _, err := range []error{
+		r.resolveDefaultRoles(o.defaultRoles),
+		r.addPrivilegedRoles(o.privilegedRoles),
+		r.resolveExclusiveRoles(o.exclusiveRoles),
+		r.addPermissionDescriptions(o.permissionDescriptions),
+	} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	r.indexPositions()
+	r.sortGrantingRoles()
+	r.indexWildcards()
+	r.sortPermissions()
+	r.indexBits()
+	r.compileMatchers()
+	r.collectWarnings()
+	if err := r.buildRealms(roleChains, opts); err != nil {
+		return nil, err
+	}
+	r.chains = make([]*RoleChain, len(roleChains))
+	for i, chain := range roleChains {
+		r.chains[i] = chain.clone()
+	}
+	r.builtAt = time.Now()
+	r.freeze(o)
+	return r, nil
+}
+
+// Precomputes the roles that give each permission ordered by seniority.
+func (r *Rbac) sortGrantingRoles() {
+	r.permissionToRolesSorted = make(map[string][]string, len(r.permissionToRoleSet))
+	for permission, roleSet := range r.permissionToRoleSet {
+		r.permissionToRolesSorted[permission] = r.SortRolesBySeniority(sortedKeys(roleSet))
+	}
+}
+
+// Precomputes the sorted permissions of every role that is not expanded lazily.
+func (r *Rbac) sortPermissions() {
+	r.roleToPermissionsSorted = make(map[string][]string, len(r.roleToPermissionSet))
+	for role := range r.roleToPermissionSet {
+		if _, ok := r.roleToExceptSet[role]; !ok {
+			r.roleToPermissionsSorted[role] = sortedKeys(r.rolePermissionSet(role))
+		}
+	}
+}
+
+// Precomputes the effective permissions of every role as a bitset for fast unions.
+func (r *Rbac) indexBits() {
+	r.permissionsSorted = sortedKeys(r.permissionToRoleSet)
+	permissionToIndex := make(map[string]int, len(r.permissionsSorted))
+	for i, permission := range r.permissionsSorted {
+		permissionToIndex[permission] = i
+	}
+	r.roleToBits = make(map[string][]uint64, len(r.roleToPermissionSet))
+	for role := range r.roleToPermissionSet {
+		bitset := make([]uint64, (len(r.permissionsSorted)+63)/64)
+		for permission := range r.rolePermissionSet(role) {
+			i := permissionToIndex[permission]
+			bitset[i/64] |= 1 << (i % 64)
+		}
+		r.roleToBits[role] = bitset
+	}
+}
+
+// Returns a sorted copy of the permissions the role gives, using the precomputed slice if there is one.
+func (r *Rbac) sortedRolePermissions(role string) []string {
+	if sorted, ok := r.roleToPermissionsSorted[role]; ok {
+		return append([]string{}, sorted...)
+	}
+	return sortedKeys(r.rolePermissionSet(role))
+}
+
+// Indexes the positions of chains and of roles within their chains.
+func (r *Rbac) indexPositions() {
+	r.chainToIndex = make(map[string]int, len(r.chainNames))
+	r.roleToIndex = make(map[string]int, len(r.roleToPermissionSet))
+	for i, chain := range r.chainNames {
+		r.chainToIndex[chain] = i
+		for j, roleName := range r.chainToRoleNames[chain] {
+			r.roleToIndex[roleName] = j
+		}
+	}
+}
+
+// Adds the permissions to the role in the chain, registering the role if it does not exist yet.
+// A non-nil except set makes the role lazily give all permissions except the excluded ones.
+func (r *Rbac) addRole(chain, roleId string, permissions []string, except map[string]bool) {
+	roleName := r.flatten(chain, roleId)
+	if _, ok := r.roleToPermissionSet[roleName]; !ok {
+		r.roleToPermissionSet[roleName] = map[string]bool{}
+		r.roleToChain[roleName] = chain
+		r.chainToRoleIdSet[chain][roleId] = true
+		r.chainToRoleNames[chain] = append(r.chainToRoleNames[chain], roleName)
+	}
+	for _, permission := range permissions {
+		if _, ok := r.permissionToRoleSet[permission]; !ok {
+			r.permissionToRoleSet[permission] = map[string]bool{}
+		}
+		r.permissionToRoleSet[permission][roleName] = true
+		r.roleToPermissionSet[roleName][permission] = true
+	}
+	if except == nil {
+		return
+	}
+	if existing, ok := r.roleToExceptSet[roleName]; ok {
+		// The union of two all-except roles only excludes what both exclude.
+		intersection := map[string]bool{}
+		for permission := range except {
+			if existing[permission] {
+				intersection[permission] = true
+			}
+		}
+		except = intersection
+	}
+	r.roleToExceptSet[roleName] = except
+}
+
+// Removes the role and all its permissions.
+func (r *Rbac) removeRole(roleName string) {
+	chain := r.roleToChain[roleName]
+	delete(r.chainToRoleIdSet[chain], strings.TrimPrefix(roleName, chain+r.roleSeparator()))
+	for i, name := range r.chainToRoleNames[chain] {
+		if name == roleName {
+			r.chainToRoleNames[chain] = append(r.chainToRoleNames[chain][:i:i], r.chainToRoleNames[chain][i+1:]...)
+			break
+		}
+	}
+	for permission := range r.roleToPermissionSet[roleName] {
+		delete(r.permissionToRoleSet[permission], roleName)
+		if len(r.permissionToRoleSet[permission]) == 0 {
+			delete(r.permissionToRoleSet, permission)
+		}
+	}
+	delete(r.roleToPermissionSet, roleName)
+	delete(r.roleToExceptSet, roleName)
+	delete(r.roleToChain, roleName)
+	delete(r.roleToParent, roleName)
+	delete(r.roleToGate, roleName)
+	delete(r.superAdminSet, roleName)
+	for permission := range r.roleToDenySet[roleName] {
+		delete(r.permissionToDenyRoleSet[permission], roleName)
+		if len(r.permissionToDenyRoleSet[permission]) == 0 {
+			delete(r.permissionToDenyRoleSet, permission)
+		}
+	}
+	delete(r.roleToDenySet, roleName)
+}
+
+// Returns whether the role counts in checks, i.e. it is not gated or its gate is enabled.
+func (r *Rbac) roleEnabled(role string) bool {
+	gate, ok := r.roleToGate[role]
+	return !ok || gate()
+}
+
+// Returns whether the role gives the permission, evaluating lazily expanded roles on demand.
+func (r *Rbac) roleGives(role, permission string) bool {
+	if r.roleToPermissionSet[role][permission] || r.superAdminSet[role] || r.roleToCoveredSet[role][permission] {
+		return true
+	}
+	if except, ok := r.roleToExceptSet[role]; ok {
+		_, known := r.permissionToRoleSet[permission]
+		return known && !except[permission]
+	}
+	return false
+}
+
+// Returns the permissions the role gives, expanding lazily expanded roles. Must not be mutated.
+func (r *Rbac) rolePermissionSet(role string) map[string]bool {
+	if r.superAdminSet[role] {
+		permissionSet := make(map[string]bool, len(r.permissionToRoleSet))
+		for permission := range r.permissionToRoleSet {
+			permissionSet[permission] = true
 		}
+		return permissionSet
+	}
+	return r.grantedPermissionSet(role)
+}
+
+// Returns the permissions the role gives, not counting it as a super admin or privileged role. Must not be
+// mutated.
+func (r *Rbac) grantedPermissionSet(role string) map[string]bool {
+	if covered, ok := r.roleToCoveredSet[role]; ok {
+		return covered
+	}
+	except, ok := r.roleToExceptSet[role]
+	if !ok {
+		return r.roleToPermissionSet[role]
+	}
+	permissionSet := map[string]bool{}
+	for permission := range r.permissionToRoleSet {
+		if !except[permission] || r.roleToPermissionSet[role][permission] {
+			permissionSet[permission] = true
+		}
+	}
+	return permissionSet
+}
+
+// Returns the roles that give the transformed permission, including lazily expanded roles. Must not be mutated.
+func (r *Rbac) permissionRoleSet(permission string) map[string]bool {
+	roleSet, known := r.permissionToRoleSet[permission]
+	wildcardRoles := r.wildcardGrantingRoles(permission)
+	if len(wildcardRoles) == 0 && (!known || (len(r.roleToExceptSet) == 0 && len(r.superAdminSet) == 0)) {
+		return roleSet
+	}
+	expanded := map[string]bool{}
+	for role := range roleSet {
+		expanded[role] = true
+	}
+	for _, role := range wildcardRoles {
+		expanded[role] = true
+	}
+	for role := range r.roleToExceptSet {
+		if r.roleGives(role, permission) {
+			expanded[role] = true
+		}
+	}
+	for role := range r.superAdminSet {
+		expanded[role] = true
+	}
+	return expanded
+}
+
+// Returns the chain names in registration order, i.e. the order they were passed to NewRbac.
+func (r *Rbac) ChainNames() []string {
+	return append([]string{}, r.chainNames...)
+}
+
+// Documentation of a chain.
+type ChainInfo struct {
+	// The name of the chain.
+	Name string
+	// What the chain represents, empty if not described.
+	Description string
+	// The flattened role names in the order they extend each other.
+	Roles []string
+}
+
+// Returns the documentation of a chain or an error if it does not exist.
+func (r *Rbac) ChainInfo(name string) (ChainInfo, error) {
+	roles, ok := r.chainToRoleNames[name]
+	if !ok {
+		return ChainInfo{}, fmt.Errorf("unknown chain %s", name)
 	}
-	return &Rbac{
-		permissionToRoleSet: permissionToRoleSet,
-		chainToRoleIdSet:    chainToRoleIdSet,
-		roleToPermissionSet: roleToPermissionSet,
+	return ChainInfo{
+		Name:        name,
+		Description: r.chainToDescription[name],
+		Roles:       append([]string{}, roles...),
 	}, nil
 }
 
+// Returns all flattened role names sorted.
+func (r *Rbac) AllRoles() []string {
+	r.assertFrozen()
+	return sortedKeys(r.roleToPermissionSet)
+}
+
+// Returns all permissions given by any role sorted.
+func (r *Rbac) AllPermissions() []string {
+	r.assertFrozen()
+	return sortedKeys(r.permissionToRoleSet)
+}
+
+// Calls f for every pair of a role and a permission it gives, sorted by role and then permission, until f returns
+// false. Unlike combining AllRoles and PermissionsForRole it does not copy the permissions of each role.
+func (r *Rbac) Range(f func(role, permission string) bool) {
+	r.assertFrozen()
+	for _, role := range sortedKeys(r.roleToPermissionSet) {
+		permissions, ok := r.roleToPermissionsSorted[role]
+		if !ok {
+			permissions = sortedKeys(r.rolePermissionSet(role))
+		}
+		for _, permission := range permissions {
+			if !f(role, permission) {
+				return
+			}
+		}
+	}
+}
+
+// Calls f with every chain in registration order and copies of its roles in order with their sorted effective
+// permissions, e.g. for a documentation generator that needs the hierarchy, until f returns false.
+// Mutating the roles does not affect the rbac.
+func (r *Rbac) EachChain(f func(chain string, roles []*Role) bool) {
+	r.assertFrozen()
+	for _, chain := range r.chainNames {
+		roles := make([]*Role, 0, len(r.chainToRoleNames[chain]))
+		for _, role := range r.chainToRoleNames[chain] {
+			roles = append(roles, &Role{
+				Id:          role[len(chain)+len(r.roleSeparator()):],
+				Permissions: r.sortedRolePermissions(role),
+			})
+		}
+		if !f(chain, roles) {
+			return
+		}
+	}
+}
+
+// Returns the sorted permissions every role gives, which are effectively public and may be better modeled as such.
+// Empty if there are none or no roles.
+func (r *Rbac) UniversalPermissions() []string {
+	r.assertFrozen()
+	universal := []string{}
+	for _, permission := range sortedKeys(r.permissionToRoleSet) {
+		all := true
+		for role := range r.roleToPermissionSet {
+			if !r.roleGives(role, permission) {
+				all = false
+				break
+			}
+		}
+		if all {
+			universal = append(universal, permission)
+		}
+	}
+	return universal
+}
+
+// Calls f for every permission in sorted order until f returns false, without building a slice like
+// AllPermissions, e.g. to stream the permissions of a huge model.
+func (r *Rbac) EachPermission(f func(permission string) bool) {
+	r.assertFrozen()
+	for _, permission := range r.permissionsSorted {
+		if !f(permission) {
+			return
+		}
+	}
+}
+
+// Returns the sorted flattened role names that give the permission, empty if none do.
+func (r *Rbac) RolesWithPermission(permission string) []string {
+	r.assertFrozen()
+	return sortedKeys(r.permissionRoleSet(r.transformPermission(permission)))
+}
+
+// Returns the sorted flattened role names that give the permission, empty if none do. It is the same as
+// RolesWithPermission, named after PermissionsForRole.
+func (r *Rbac) RolesForPermission(permission string) []string {
+	return r.RolesWithPermission(permission)
+}
+
+// Returns the sorted names of the chains with at least one role that gives the permission.
+func (r *Rbac) ChainsWithPermission(permission string) []string {
+	r.assertFrozen()
+	chainSet := map[string]bool{}
+	for role := range r.permissionRoleSet(r.transformPermission(permission)) {
+		chainSet[r.roleToChain[role]] = true
+	}
+	return sortedKeys(chainSet)
+}
+
+// Returns every permission with the sorted roles that give it. The map is computed once and a copy is
+// returned on every call, so it can be freely modified.
+func (r *Rbac) PermissionRoleMap() map[string][]string {
+	r.permissionRoleMapOnce.Do(func() {
+		r.permissionRoleMap = make(map[string][]string, len(r.permissionToRoleSet))
+		for permission := range r.permissionToRoleSet {
+			r.permissionRoleMap[permission] = r.RolesWithPermission(permission)
+		}
+	})
+	permissionRoleMap := make(map[string][]string, len(r.permissionRoleMap))
+	for permission, roles := range r.permissionRoleMap {
+		permissionRoleMap[permission] = append([]string{}, roles...)
+	}
+	return permissionRoleMap
+}
+
+// Returns the sorted permissions the flattened role gives, or an error if the role does not exist.
+func (r *Rbac) PermissionsForRole(role string) ([]string, error) {
+	r.assertFrozen()
+	if _, ok := r.roleToPermissionSet[role]; !ok {
+		return nil, fmt.Errorf("role %s not found", role)
+	}
+	return r.sortedRolePermissions(role), nil
+}
+
+// Returns the sorted permissions the candidate role gives that none of the existing roles give.
+// Unknown existing roles are ignored and an unknown candidate adds nothing.
+func (r *Rbac) PermissionsAdded(existing []string, candidate string) []string {
+	covered := map[string]bool{}
+	for _, role := range existing {
+		for permission := range r.rolePermissionSet(role) {
+			covered[permission] = true
+		}
+	}
+	added := map[string]bool{}
+	for permission := range r.rolePermissionSet(candidate) {
+		if !covered[permission] {
+			added[permission] = true
+		}
+	}
+	return sortedKeys(added)
+}
+
+// Returns the sorted forbidden permissions the candidate role would give on top of the current roles, e.g. to
+// guard an automated role assignment against a denylist. Empty means the candidate is safe to add.
+// Unknown current roles are ignored and an unknown candidate gives nothing.
+func (r *Rbac) WouldExceed(current []string, candidate string, forbidden []string) []string {
+	exceeded := map[string]bool{}
+	for _, permission := range r.transformPermissions(forbidden) {
+		if !r.roleGives(candidate, permission) {
+			continue
+		}
+		covered := false
+		for _, role := range current {
+			if r.roleGives(role, permission) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			exceeded[permission] = true
+		}
+	}
+	return sortedKeys(exceeded)
+}
+
+// Returns the sorted permissions the flattened role gives beyond the role it extends, i.e. what this tier adds.
+// Roles that do not extend another role, like the first role of a chain, return all their permissions and
+// unknown roles return none.
+func (r *Rbac) RoleDelta(role string) []string {
+	existing := []string{}
+	if parent, ok := r.roleToParent[role]; ok {
+		existing = append(existing, parent)
+	}
+	return r.PermissionsAdded(existing, role)
+}
+
+// Returns the sorted permissions only roleA gives and the ones only roleB gives, e.g. to decide whether two
+// similar roles can be consolidated. An unknown role gives no permissions, so its side is empty and the other
+// side lists all permissions of the other role.
+func (r *Rbac) RolePermissionDiff(roleA, roleB string) (onlyA, onlyB []string) {
+	return r.PermissionsAdded([]string{roleB}, roleA), r.PermissionsAdded([]string{roleA}, roleB)
+}
+
+// Returns the sorted permissions the flattened role effectively gives, empty for an unknown role.
+// All-except roles are expanded against the known permission universe, i.e. the permissions any role gives.
+func (r *Rbac) EffectivePermissionsForRole(role string) []string {
+	r.assertFrozen()
+	return r.sortedRolePermissions(role)
+}
+
+// Returns the permissions the role effectively gives after every expansion. Must not be mutated.
+func (r *Rbac) effectivePermissionSet(role string) map[string]bool {
+	return r.rolePermissionSet(role)
+}
+
+// Returns the pairs of (role, previousRole) where a role grants exactly the same permissions as the role
+// before it in its chain, which usually means it was added with no new permissions by mistake.
+// Pairs are ordered by chain registration order and then role order.
+func (r *Rbac) RedundantRoles() [][2]string {
+	redundant := [][2]string{}
+	for _, chain := range r.chainNames {
+		roleNames := r.chainToRoleNames[chain]
+		for i := 1; i < len(roleNames); i++ {
+			if equalSets(r.rolePermissionSet(roleNames[i]), r.rolePermissionSet(roleNames[i-1])) {
+				redundant = append(redundant, [2]string{roleNames[i], roleNames[i-1]})
+			}
+		}
+	}
+	return redundant
+}
+
+// Collects the empty roles, redundant roles and permissions only given by a single role.
+func (r *Rbac) collectWarnings() {
+	r.warnings = []string{}
+	for _, chain := range r.chainNames {
+		for _, role := range r.chainToRoleNames[chain] {
+			if len(r.rolePermissionSet(role)) == 0 {
+				r.warnings = append(r.warnings, fmt.Sprintf("role %s gives no permissions", role))
+			}
+		}
+	}
+	for _, pair := range r.RedundantRoles() {
+		r.warnings = append(r.warnings, fmt.Sprintf("role %s gives the same permissions as %s", pair[0], pair[1]))
+	}
+	for _, permission := range sortedKeys(r.permissionToRoleSet) {
+		if roles := r.permissionRoleSet(permission); len(roles) == 1 {
+			r.warnings = append(r.warnings, fmt.Sprintf("permission %q is only given by role %s", permission, sortedKeys(roles)[0]))
+		}
+	}
+}
+
+// Returns the non-fatal model smells found when building in a deterministic order: roles that give no
+// permissions, roles that give the same permissions as the role before them and permissions given by a single role.
+func (r *Rbac) Warnings() []string {
+	return append([]string{}, r.warnings...)
+}
+
+// Returns whether both sets contain the same keys.
+func equalSets(a, b map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key := range a {
+		if !b[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns whether the flattened role exists.
+func (r *Rbac) IsValidRole(flattened string) bool {
+	return r.ValidateRole(flattened) == nil
+}
+
+// Returns an error if the flattened role is not in the format {chainName}.{roleId}, using the configured role
+// separator, or does not exist, telling whether the chain or the role id is unknown.
+func (r *Rbac) ValidateRole(flattened string) error {
+	if _, ok := r.roleToPermissionSet[flattened]; ok {
+		return nil
+	}
+	sep := r.roleSeparator()
+	if !strings.Contains(strings.Trim(flattened, sep), sep) {
+		return fmt.Errorf("role %q is not in the format {chainName}%s{roleId}", flattened, sep)
+	}
+	chain := ""
+	for _, name := range r.chainNames {
+		if strings.HasPrefix(flattened, name+sep) && len(name) > len(chain) {
+			chain = name
+		}
+	}
+	if chain == "" {
+		return fmt.Errorf("role %q has an unknown chain", flattened)
+	}
+	return fmt.Errorf("chain %s has no role %s", chain, strings.TrimPrefix(flattened, chain+sep))
+}
+
+// Returns the invalid roles per subject of the assignments, e.g. for validating a bulk import in one pass.
+// Role aliases are resolved like in Add. Only subjects with at least one invalid role are included.
+func (r *Rbac) ValidateAssignments(assignments map[string][]string) map[string][]string {
+	invalid := map[string][]string{}
+	for subject, roles := range assignments {
+		for _, role := range roles {
+			if _, ok := r.roleToPermissionSet[r.resolveRole(role)]; !ok {
+				invalid[subject] = append(invalid[subject], role)
+			}
+		}
+	}
+	return invalid
+}
+
+// Returns a sorted copy of the roles from least to most senior, i.e. by their position within their chain.
+// Roles at the same position are ordered by the registration order of their chains and then by name.
+// Unknown roles come last ordered by name.
+func (r *Rbac) SortRolesBySeniority(roles []string) []string {
+	sorted := append([]string{}, roles...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		aIndex, aKnown := r.roleToIndex[a]
+		bIndex, bKnown := r.roleToIndex[b]
+		if aKnown != bKnown {
+			return aKnown
+		}
+		if aKnown && aIndex != bIndex {
+			return aIndex < bIndex
+		}
+		if aKnown {
+			if aChain, bChain := r.chainToIndex[r.roleToChain[a]], r.chainToIndex[r.roleToChain[b]]; aChain != bChain {
+				return aChain < bChain
+			}
+		}
+		return a < b
+	})
+	return sorted
+}
+
+// Returns the flattened role in the same chain whose permissions the given role extends,
+// or false for the first role of a chain, independent roles and unknown roles.
+func (r *Rbac) RoleExtends(role string) (parent string, ok bool) {
+	parent, ok = r.roleToParent[role]
+	return parent, ok
+}
+
+// Returns whether the flattened role is the most privileged in its chain, i.e. no other role in the chain extends
+// it, or false for unknown roles.
+func (r *Rbac) IsTopRole(role string) bool {
+	chain, ok := r.roleToChain[role]
+	if !ok {
+		return false
+	}
+	for _, other := range r.chainToRoleNames[chain] {
+		if r.roleToParent[other] == role {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the sorted names of the chains that define the role id, empty if none do.
+func (r *Rbac) RoleIdChains(roleId string) []string {
+	chains := []string{}
+	for chain, roleIdSet := range r.chainToRoleIdSet {
+		if roleIdSet[roleId] {
+			chains = append(chains, chain)
+		}
+	}
+	sort.Strings(chains)
+	return chains
+}
+
 // Returns whether the role id exists in the given chain.
 func (r *Rbac) ChainHasRoleId(chain string, roleId string) bool {
 	if _, ok := r.chainToRoleIdSet[chain]; !ok {
@@ -92,7 +1045,12 @@ func (r *Rbac) ChainHasRoleId(chain string, roleId string) bool {
 	return true
 }
 
-// An authorizer with a list of roles.
+// An authorizer with a list of roles. It is safe for concurrent use.
+//
+// A role added with Add is visible to every check that starts after Add returns, i.e. any check in the same
+// goroutine or in a goroutine synchronized with it, e.g. via a channel or mutex. A check running concurrently
+// with Add may or may not see the role. Roles added with AddAsync are always visible to checks, which wait for
+// all async role additions scheduled before they started.
 type Authorizer struct {
 	// The rbac this belongs to.
 	rbac *Rbac
@@ -102,74 +1060,713 @@ type Authorizer struct {
 	wg sync.WaitGroup
 	// Any errors that occurred during async role additions.
 	errors sync.Map
+	// The subject the roles belong to.
+	subject string
+	// The matcher overriding the one of the rbac if not nil.
+	matcher Matcher
+	// Incremented after roles are added, invalidating the bloom filter.
+	roleGeneration atomic.Uint64
+	// The bloom filter of the permissions of the roles if enabled, built lazily.
+	bloom atomic.Pointer[bloomFilter]
+	// Whether any async role additions were scheduled.
+	hadAsync atomic.Bool
+	// How long the last wait for async role additions blocked in nanoseconds.
+	waitDuration atomic.Int64
+	// Whether the roles that give checked permissions are recorded.
+	trackUsage atomic.Bool
+	// The roles that gave at least one checked permission.
+	used sync.Map
+	// The roles added for each resource, each a *sync.Map of roles.
+	scopes sync.Map
+	// The permissions denied to this authorizer regardless of its roles.
+	denies sync.Map
+	// The expiry time of each expiring role.
+	expiries sync.Map
+	// Whether any expiring roles were added.
+	hasExpiring atomic.Bool
+	// Called with every expiring role that lapsed if not nil.
+	onRoleExpired atomic.Pointer[func(role string)]
+	// The context error of the last check with a context, nil if it completed.
+	lastError atomic.Pointer[error]
 }
 
 // Returns an authorizer to add roles to.
+// If the rbac was not built with NewRbac, e.g. a nil or zero value, the authorizer denies everything and Err
+// reports it.
 func (r *Rbac) Authorizer(roles ...string) *Authorizer {
+	r.assertFrozen()
 	er := &Authorizer{
 		rbac:   r,
 		roles:  sync.Map{},
 		wg:     sync.WaitGroup{},
 		errors: sync.Map{},
 	}
+	er.guardUninitialized()
+	er.applyDefaults()
 	er.Add(roles...)
 	return er
 }
 
-// Directly adds one/more roles.
+// An empty rbac used by authorizers of an rbac that was not built with NewRbac.
+var uninitializedRbac = &Rbac{config: &options{}}
+
+// Replaces an rbac that was not built with NewRbac with an empty one and records an error.
+func (a *Authorizer) guardUninitialized() {
+	if a.rbac == nil || a.rbac.config == nil {
+		a.rbac = uninitializedRbac
+		a.errors.Store("rbac not initialized, build it with NewRbac", true)
+	}
+}
+
+// Returns an authorizer per subject with the subject's roles, allocating all authorizers at once.
+// Each authorizer behaves like one returned by Authorizer with WithSubject set to its subject.
+func (r *Rbac) Authorizers(roleSets map[string][]string) map[string]*Authorizer {
+	authorizers := make(map[string]*Authorizer, len(roleSets))
+	backing := make([]Authorizer, len(roleSets))
+	i := 0
+	for subject, roles := range roleSets {
+		a := &backing[i]
+		i++
+		a.rbac = r
+		a.subject = subject
+		a.guardUninitialized()
+		a.applyDefaults()
+		a.Add(roles...)
+		authorizers[subject] = a
+	}
+	return authorizers
+}
+
+// Sets the subject (e.g. user id) the roles belong to, used for logging.
+// Should be called before the authorizer is shared across goroutines.
+func (a *Authorizer) WithSubject(subject string) *Authorizer {
+	a.subject = subject
+	return a
+}
+
+// Returns the subject the roles belong to.
+func (a *Authorizer) Subject() string {
+	return a.subject
+}
+
+// Directly adds one/more roles, visible to all checks that start after it returns.
+// Role aliases are resolved to the roles they point at and default roles are removed unless they always apply.
+// Unknown roles are stored as is unless the rbac was built WithStrictRoleAdditions.
 func (a *Authorizer) Add(roles ...string) {
+	added := false
 	for _, role := range roles {
+		resolved := a.rbac.resolveRole(role)
+		if _, ok := a.rbac.roleToPermissionSet[resolved]; !ok && a.rbac.config.strictRoleAdditions {
+			a.errors.Store(fmt.Sprintf("role %s not allowed", role), true)
+			continue
+		}
+		role = resolved
 		a.roles.Store(role, true)
+		added = true
+		if a.hasExpiring.Load() {
+			a.expiries.Delete(role)
+		}
+	}
+	if added {
+		a.roleGeneration.Add(1)
+		a.suppressDefaults()
 	}
 }
 
-// Asynchronously adds one/more roles.
+// Removes one/more roles after waiting for async role additions, e.g. after a step-down, so checks that start
+// after it returns no longer see them. Because it waits, a role that an async addition scheduled before Remove
+// adds is removed as well, while async additions scheduled after Remove may add it again. Role aliases are
+// resolved like in Add. Roles scoped to resources with AddScoped are not removed.
+func (a *Authorizer) Remove(roles ...string) {
+	a.wait()
+	for _, role := range roles {
+		role = a.rbac.resolveRole(role)
+		a.roles.Delete(role)
+		a.expiries.Delete(role)
+	}
+	if len(roles) > 0 {
+		a.roleGeneration.Add(1)
+	}
+}
+
+// Adds the roles that exist like Add and returns an error naming every role that does not exist, which is not
+// added, so a misspelled role never grants anything regardless of WithStrictRoleAdditions.
+func (a *Authorizer) AddValidated(roles ...string) error {
+	valid, errors := a.rbac.validRoles(roles)
+	if len(valid) > 0 {
+		a.Add(valid...)
+	}
+	if len(errors) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(errors, "; "))
+}
+
+// Asynchronously adds one/more roles. Roles that do not exist are not added and Err reports them.
+// A panic in f is recovered and recorded as an error reported by Err.
 func (a *Authorizer) AddAsync(f func() ([]string, error)) {
+	a.addAsync("role", f, a.addRoles, nil)
+}
+
+// Asynchronously adds one/more roles like AddAsync and returns a channel that receives the errors of this
+// particular addition (nil if none) and closes once its roles are added, to await it without waiting for all
+// other async role additions. Its errors are still reported by Err as well.
+func (a *Authorizer) AddAsyncChan(f func() ([]string, error)) <-chan error {
+	done := make(chan error, 1)
+	a.addAsync("role", f, a.addRoles, func(err error) {
+		done <- err
+		close(done)
+	})
+	return done
+}
+
+// Asynchronously adds one/more roles like AddAsync, passing ctx to f. If ctx is done before f returns, checks
+// stop waiting for f, its roles are discarded even if they arrive later and Err reports e.g.
+// "role resolution cancelled: context deadline exceeded". f should still return once ctx is done.
+func (a *Authorizer) AddAsyncCtx(ctx context.Context, f func(context.Context) ([]string, error)) {
+	a.addAsync("role", func() ([]string, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("role resolution cancelled: %w", err)
+		}
+		type result struct {
+			roles     []string
+			err       error
+			recovered any
+		}
+		results := make(chan result, 1)
+		go func() {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					results <- result{recovered: recovered}
+				}
+			}()
+			roles, err := f(ctx)
+			results <- result{roles: roles, err: err}
+		}()
+		select {
+		case res := <-results:
+			if res.recovered != nil {
+				panic(res.recovered)
+			}
+			return res.roles, res.err
+		case <-ctx.Done():
+			return nil, fmt.Errorf("role resolution cancelled: %w", ctx.Err())
+		}
+	}, a.addRoles, nil)
+}
+
+// Asynchronously applies the values returned by f, e.g. roles, recording any errors of f and apply and calling
+// done with them if not nil. The kind of values names them in the panic error.
+func (a *Authorizer) addAsync(kind string, f func() ([]string, error), apply func([]string) []string, done func(error)) {
+	a.hadAsync.Store(true)
 	a.wg.Add(1)
 	go func() {
+		errors := []string{}
 		defer a.wg.Done()
-		roles, err := f()
-		if err != nil {
-			a.errors.Store(err.Error(), true)
-		}
-		for _, role := range roles {
-			if _, ok := a.rbac.roleToPermissionSet[role]; !ok {
-				a.errors.Store(fmt.Sprintf("role %s not allowed", role), true)
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errors = append(errors, fmt.Sprintf("async %s addition panicked: %v", kind, recovered))
+			}
+			for _, err := range errors {
+				a.errors.Store(err, true)
+			}
+			if done == nil {
+				return
+			}
+			if len(errors) == 0 {
+				done(nil)
+				return
 			}
+			done(fmt.Errorf("%s", strings.Join(errors, "; ")))
+		}()
+		values, err := f()
+		if err != nil {
+			errors = append(errors, err.Error())
 		}
-		a.Add(roles...)
+		errors = append(errors, apply(values)...)
 	}()
 }
 
-// Returns a combined error of all sync and async errors that occurred if any.
+// Adds the roles that exist and returns an error message for every role that does not exist, which is not added.
+func (a *Authorizer) addRoles(roles []string) []string {
+	valid, errors := a.rbac.validRoles(roles)
+	a.Add(valid...)
+	return errors
+}
+
+// Returns the roles that exist and an error message for every role that does not exist, resolving aliases.
+func (r *Rbac) validRoles(roles []string) ([]string, []string) {
+	valid := make([]string, 0, len(roles))
+	errors := []string{}
+	for _, role := range roles {
+		if _, ok := r.roleToPermissionSet[r.resolveRole(role)]; !ok {
+			errors = append(errors, fmt.Sprintf("role %s not allowed", role))
+			continue
+		}
+		valid = append(valid, role)
+	}
+	return valid, errors
+}
+
+// Returns an error message for every role that does not exist, resolving aliases.
+func (r *Rbac) invalidRoleErrors(roles []string) []string {
+	_, errors := r.validRoles(roles)
+	return errors
+}
+
+// Returns a combined error of all sync and async errors that occurred and of held exclusive roles if any.
 func (a *Authorizer) Err() error {
-	a.wg.Wait()
+	a.wait()
 	errors := []string{}
 	a.errors.Range(func(key, value interface{}) bool {
 		errors = append(errors, key.(string))
 		return true
 	})
+	errors = append(errors, a.exclusiveConflicts()...)
 	if len(errors) == 0 {
 		return nil
 	}
 	return fmt.Errorf("%s", strings.Join(errors, "; "))
 }
 
-// Returns whether one of the roles give the specified permission.
+// Returns whether one of the roles give the specified permission, or the external fallback if the rbac has one.
 func (a *Authorizer) HasPermission(permission string) bool {
-	a.wg.Wait()
-	rolesThatGiveAccess := a.rbac.permissionToRoleSet[permission]
-	for role := range rolesThatGiveAccess {
-		if _, ok := a.roles.Load(role); ok {
+	a.wait()
+	return a.gives(a.rbac.transformPermission(permission))
+}
+
+// Returns whether every permission is given like HasPermission after waiting for async role additions once.
+// Returns true without permissions.
+func (a *Authorizer) HasAllPermissions(permissions ...string) bool {
+	a.wait()
+	for _, permission := range permissions {
+		if !a.gives(a.rbac.transformPermission(permission)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns whether at least one permission is given like HasPermission after waiting for async role additions
+// once. Returns false without permissions.
+func (a *Authorizer) HasAnyPermission(permissions ...string) bool {
+	a.wait()
+	for _, permission := range permissions {
+		if a.gives(a.rbac.transformPermission(permission)) {
 			return true
 		}
 	}
 	return false
 }
 
+// Returns whether the roles or the external fallback give the transformed permission without waiting.
+func (a *Authorizer) gives(permission string) bool {
+	return a.decide(context.Background(), permission)
+}
+
+// Returns whether the global roles, one of the other sources, e.g. scoped roles, or else the external fallback give
+// the transformed permission without waiting. Every check decides through it.
+func (a *Authorizer) decide(ctx context.Context, permission string, sources ...func(permission string) bool) bool {
+	if a.hasPermission(permission) {
+		return true
+	}
+	for _, gives := range sources {
+		if gives(permission) {
+			return true
+		}
+	}
+	return a.externalGives(ctx, permission)
+}
+
+// Returns whether one of the roles give the specified permission and whether the permission is defined in
+// the rbac at all, to tell an undefined permission (usually a programmer error) from one that is not granted.
+func (a *Authorizer) CheckPermissionDefined(permission string) (granted bool, defined bool) {
+	a.wait()
+	permission = a.rbac.transformPermission(permission)
+	return a.gives(permission), a.rbac.isDefined(permission)
+}
+
+// Returns whether one of the roles give the specified permission like HasPermission and calls audit with the
+// result before returning, so every decision at the call site is audited.
+func (a *Authorizer) HasPermissionAudited(permission string, audit func(granted bool)) bool {
+	granted := a.HasPermission(permission)
+	audit(granted)
+	return granted
+}
+
+// Returns def if the permission is not defined in the rbac, otherwise whether one of the roles give it.
+// Allows staged rollouts where a new permission is allowed by default until it is defined.
+func (a *Authorizer) HasPermissionOr(permission string, def bool) bool {
+	a.wait()
+	permission = a.rbac.transformPermission(permission)
+	if !a.rbac.isDefined(permission) {
+		return def
+	}
+	return a.gives(permission)
+}
+
+// Returns whether any role gives the transformed permission, conditionally or not, exactly or with a wildcard
+// permission.
+func (r *Rbac) isDefined(permission string) bool {
+	if _, ok := r.permissionToRoleSet[permission]; ok {
+		return true
+	}
+	if _, ok := r.permissionToRoleConditions[permission]; ok {
+		return true
+	}
+	return len(r.wildcardGrantingRoles(permission)) > 0
+}
+
+// Returns whether one of the roles give the specified permission, waiting at most d for async role additions.
+// The second return value reports whether the wait timed out, in which case the permission is denied.
+func (a *Authorizer) HasPermissionWithin(d time.Duration, permission string) (bool, bool) {
+	if !a.waitWithin(d) {
+		return false, true
+	}
+	return a.gives(a.rbac.transformPermission(permission)), false
+}
+
+// Returns whether one of the roles give the specified permission, waiting for async role additions until the
+// context is done. A check with a done context is a deny and LastError reports the context error.
+func (a *Authorizer) HasPermissionCtx(ctx context.Context, permission string) bool {
+	if err := a.waitCtx(ctx); err != nil {
+		a.lastError.Store(&err)
+		return false
+	}
+	a.lastError.Store(nil)
+	return a.decide(ctx, a.rbac.transformPermission(permission))
+}
+
+// Returns the context error of the last HasPermissionCtx check, nil if it was not cancelled.
+func (a *Authorizer) LastError() error {
+	if err := a.lastError.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// Waits for async role additions until the context is done and returns the context error if it is.
+func (a *Authorizer) waitCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	go func() {
+		a.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Waits for async role additions, recording how long it blocked. If none were ever scheduled it does not touch
+// the wait group at all, which keeps synchronous checks like HasRole cheap.
+func (a *Authorizer) wait() {
+	if a.hadAsync.Load() {
+		start := time.Now()
+		a.wg.Wait()
+		a.waitDuration.Store(int64(time.Since(start)))
+	}
+	a.expireRoles()
+}
+
+// Returns whether any async role additions were scheduled, for diagnosing authorization latency.
+func (a *Authorizer) HadAsync() bool {
+	return a.hadAsync.Load()
+}
+
+// Returns how long the last wait for async role additions blocked, zero if none were scheduled.
+func (a *Authorizer) WaitDuration() time.Duration {
+	return time.Duration(a.waitDuration.Load())
+}
+
+// Waits at most d for async role additions and returns whether they all completed.
+func (a *Authorizer) waitWithin(d time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-done:
+		a.expireRoles()
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Returns whether one of the roles give the transformed permission without waiting for async role additions.
+// An empty permission and denied permissions are always denied.
+func (a *Authorizer) hasPermission(permission string) bool {
+	a.rbac.assertFrozen()
+	if permission == "" {
+		return false
+	}
+	if a.isDenied(permission) || a.bloomRejects(permission) {
+		return false
+	}
+	if a.trackUsage.Load() {
+		granting := a.rbac.SortRolesBySeniority(a.grantingRoles(permission))
+		if len(granting) == 0 {
+			return false
+		}
+		a.used.Store(granting[0], true)
+		return true
+	}
+	for role := range a.rbac.superAdminSet {
+		if _, ok := a.roles.Load(role); ok && a.rbac.roleEnabled(role) {
+			return true
+		}
+	}
+	for _, role := range a.rbac.permissionToRolesSorted[permission] {
+		if _, ok := a.roles.Load(role); ok && a.rbac.roleEnabled(role) {
+			return true
+		}
+	}
+	for _, role := range a.rbac.wildcardGrantingRoles(permission) {
+		if _, ok := a.roles.Load(role); ok && a.rbac.roleEnabled(role) {
+			return true
+		}
+	}
+	for role := range a.rbac.roleToExceptSet {
+		if _, ok := a.roles.Load(role); ok && a.rbac.roleGives(role, permission) && a.rbac.roleEnabled(role) {
+			return true
+		}
+	}
+	return a.matches(permission)
+}
+
+// Returns all of the sorted roles that give the permission after waiting for async role additions,
+// e.g. to see whether removing one role actually revokes access.
+func (a *Authorizer) WhoGrants(permission string) []string {
+	a.wait()
+	granting := a.grantingRoles(a.rbac.transformPermission(permission))
+	sort.Strings(granting)
+	return granting
+}
+
+// Returns all roles that give the transformed permission in a stable order without waiting for async role
+// additions.
+func (a *Authorizer) grantingRoles(permission string) []string {
+	granting := []string{}
+	seen := map[string]bool{}
+	grant := func(role string) {
+		if _, ok := a.roles.Load(role); ok && !seen[role] && a.rbac.roleEnabled(role) {
+			granting = append(granting, role)
+			seen[role] = true
+		}
+	}
+	for _, role := range a.rbac.permissionToRolesSorted[permission] {
+		grant(role)
+	}
+	for _, role := range a.rbac.SortRolesBySeniority(a.rbac.wildcardGrantingRoles(permission)) {
+		grant(role)
+	}
+	for _, role := range sortedKeys(a.rbac.roleToExceptSet) {
+		if a.rbac.roleGives(role, permission) {
+			grant(role)
+		}
+	}
+	for _, role := range sortedKeys(a.rbac.superAdminSet) {
+		grant(role)
+	}
+	if len(granting) > 0 {
+		return granting
+	}
+	return a.matchingRoles(permission, false)
+}
+
 // Returns whether one of the roles are the given role.
 func (a *Authorizer) HasRole(role string) bool {
-	a.wg.Wait()
+	a.wait()
+	return a.hasRole(role)
+}
+
+// Returns whether none of the roles are the given role after waiting for async role additions once,
+// e.g. to allow unless a banned role is present. Use NotRole for the same in a role policy.
+func (a *Authorizer) LacksRole(role string) bool {
+	a.wait()
+	return !a.hasRole(role)
+}
+
+// Returns whether one of the roles are the given role without waiting for async role additions.
+func (a *Authorizer) hasRole(role string) bool {
 	_, ok := a.roles.Load(role)
 	return ok
 }
+
+// Returns whether one of the roles give the permission or are the given role, e.g. a superadmin escape hatch.
+func (a *Authorizer) HasPermissionOrRole(permission, role string) bool {
+	a.wait()
+	return a.gives(a.rbac.transformPermission(permission)) || a.hasRole(role)
+}
+
+// Enables recording which roles give checked permissions, reported by UsedRoles. When several roles give a
+// permission, the least senior one is recorded. Tracking makes every check slightly slower.
+func (a *Authorizer) TrackUsage() *Authorizer {
+	a.trackUsage.Store(true)
+	return a
+}
+
+// Returns the sorted roles that gave at least one permission checked since TrackUsage was called,
+// e.g. to find tokens carrying more privileged roles than they use.
+func (a *Authorizer) UsedRoles() []string {
+	used := []string{}
+	a.used.Range(func(key, value interface{}) bool {
+		used = append(used, key.(string))
+		return true
+	})
+	sort.Strings(used)
+	return used
+}
+
+// Returns the sorted roles after waiting for async role additions.
+func (a *Authorizer) Roles() []string {
+	a.wait()
+	return a.sortedRoles()
+}
+
+// Returns the sorted roles without waiting for async role additions.
+func (a *Authorizer) sortedRoles() []string {
+	roles := []string{}
+	a.roles.Range(func(key, value interface{}) bool {
+		roles = append(roles, key.(string))
+		return true
+	})
+	sort.Strings(roles)
+	return roles
+}
+
+// Returns the sorted permissions given by all the roles after waiting for async role additions.
+func (a *Authorizer) Permissions() []string {
+	a.wait()
+	return a.sortedPermissions()
+}
+
+// Returns the sorted permissions given by all the roles whose gate is enabled and not denied without waiting for
+// async role additions.
+func (a *Authorizer) sortedPermissions() []string {
+	union := make([]uint64, (len(a.rbac.permissionsSorted)+63)/64)
+	a.roles.Range(func(key, value interface{}) bool {
+		if !a.rbac.roleEnabled(key.(string)) {
+			return true
+		}
+		for i, word := range a.rbac.roleToBits[key.(string)] {
+			union[i] |= word
+		}
+		return true
+	})
+	permissions := []string{}
+	for i, word := range union {
+		for word != 0 {
+			if permission := a.rbac.permissionsSorted[i*64+bits.TrailingZeros64(word)]; !a.isDenied(permission) {
+				permissions = append(permissions, permission)
+			}
+			word &= word - 1
+		}
+	}
+	return permissions
+}
+
+// Returns the sorted roles whose permissions are covered by the other roles after waiting for async role
+// additions, e.g. to prune role claims from a token. Roles are considered in sorted order and a redundant role
+// no longer covers later ones, so removing all returned roles keeps the effective permissions. A super admin is
+// only covered by another super admin. Conditional permissions are not considered.
+func (a *Authorizer) RedundantRoles() []string {
+	a.wait()
+	kept := map[string]bool{}
+	for _, role := range a.sortedRoles() {
+		kept[role] = true
+	}
+	redundant := []string{}
+	for _, role := range a.sortedRoles() {
+		delete(kept, role)
+		if a.rbac.rolesCover(kept, role) {
+			redundant = append(redundant, role)
+		} else {
+			kept[role] = true
+		}
+	}
+	return redundant
+}
+
+// Returns whether the roles together give every permission of the role.
+func (r *Rbac) rolesCover(roles map[string]bool, role string) bool {
+	if r.superAdminSet[role] {
+		for other := range roles {
+			if r.superAdminSet[other] {
+				return true
+			}
+		}
+		return false
+	}
+	for permission := range r.rolePermissionSet(role) {
+		covered := false
+		for other := range roles {
+			if r.roleGives(other, permission) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns whether any of the roles gives the permission, as a cheap stateless check without allocating an
+// authorizer, e.g. in a tight loop. Aliases, gates, super admins and the matcher count like in HasPermission.
+func (r *Rbac) RolesGrant(permission string, roles ...string) bool {
+	r.assertFrozen()
+	permission = r.transformPermission(permission)
+	if permission == "" {
+		return false
+	}
+	for _, role := range roles {
+		role = r.resolveRole(role)
+		if r.roleEnabled(role) && r.roleGrants(role, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns whether both authorizers give exactly the same effective permissions after waiting for the async role
+// additions of both, even if they have different roles, e.g. to assert a role migration is access-neutral.
+func (a *Authorizer) EquivalentTo(other *Authorizer) bool {
+	return slices.Equal(a.Permissions(), other.Permissions())
+}
+
+// Returns the sorted keys of a map.
+func sortedKeys[V any](set map[string]V) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Returns whether one of the roles are the given role. Same as HasRole.
+func (a *Authorizer) Contains(role string) bool {
+	return a.HasRole(role)
+}
+
+// Returns the number of roles after waiting for async role additions.
+func (a *Authorizer) Len() int {
+	a.wait()
+	count := 0
+	a.roles.Range(func(key, value interface{}) bool {
+		count++
+		return true
+	})
+	return count
+}
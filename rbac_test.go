@@ -1,6 +1,15 @@
 package rbac_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -58,3 +67,1082 @@ func Test_ChainHasRoleId(t *testing.T) {
 		t.Fatal("should have Member role")
 	}
 }
+
+func Test_HasPermissionWithin(t *testing.T) {
+	az := Rbac.Authorizer("use.Account.Member")
+	if granted, timedOut := az.HasPermissionWithin(time.Second, "get"); !granted || timedOut {
+		t.Fatal("should have get permission without timing out")
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	az.AddAsync(func() ([]string, error) {
+		<-release
+		return []string{"use.Account.Admin"}, nil
+	})
+	granted, timedOut := az.HasPermissionWithin(10*time.Millisecond, "get")
+	if !timedOut {
+		t.Fatal("should have timed out")
+	}
+	if granted {
+		t.Fatal("should deny on timeout")
+	}
+}
+
+func Test_HasPermissionCtx(t *testing.T) {
+	az := Rbac.Authorizer("use.Account.Member")
+	if !az.HasPermissionCtx(context.Background(), "get") || az.LastError() != nil {
+		t.Fatal("should have get permission without an error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if az.HasPermissionCtx(ctx, "get") || az.LastError() != context.Canceled {
+		t.Fatal("should deny a done context and report its error")
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	az.AddAsync(func() ([]string, error) {
+		<-release
+		return []string{"use.Account.Admin"}, nil
+	})
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if az.HasPermissionCtx(ctx, "get") || az.LastError() != context.DeadlineExceeded {
+		t.Fatal("should deny when the context expires while waiting")
+	}
+}
+
+func Test_ChainNames(t *testing.T) {
+	names := Rbac.ChainNames()
+	if len(names) != 2 || names[0] != "auth" || names[1] != "use.Account" {
+		t.Fatalf("should list chains in registration order, got %v", names)
+	}
+	names[0] = "mutated"
+	if Rbac.ChainNames()[0] != "auth" {
+		t.Fatal("should return a copy")
+	}
+}
+
+func Test_RedundantRoles(t *testing.T) {
+	if redundant := Rbac.RedundantRoles(); len(redundant) != 0 {
+		t.Fatalf("should have no redundant roles, got %v", redundant)
+	}
+
+	chain := rbac.Chain("team")
+	chain.Add("Viewer", []string{"view"})
+	chain.Add("Reader", []string{})
+	chain.Add("Editor", []string{"edit"})
+	r, err := rbac.NewRbac(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redundant := r.RedundantRoles()
+	if len(redundant) != 1 || redundant[0] != [2]string{"team.Reader", "team.Viewer"} {
+		t.Fatalf("should report team.Reader as redundant, got %v", redundant)
+	}
+}
+
+func Test_AddAllExcept(t *testing.T) {
+	ops := rbac.Chain("ops")
+	ops.Add("Viewer", []string{"view"})
+	ops.AddAllExcept("Operator", []string{"delete", "view"})
+	ops.Add("Owner", []string{"delete"})
+	other := rbac.Chain("other").Add("Member", []string{"create", "delete"})
+	r, err := rbac.NewRbac(ops, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operator := r.Authorizer("ops.Operator")
+	if !operator.HasPermission("create") {
+		t.Fatal("operator should have create permission from another chain")
+	}
+	if !operator.HasPermission("view") {
+		t.Fatal("operator should still have view permission of the previous role")
+	}
+	if operator.HasPermission("delete") {
+		t.Fatal("operator should not have excluded delete permission")
+	}
+	if !r.Authorizer("ops.Owner").HasPermission("delete") || !r.Authorizer("ops.Owner").HasPermission("create") {
+		t.Fatal("owner should extend operator")
+	}
+}
+
+func Test_Len(t *testing.T) {
+	az := Rbac.Authorizer("auth.Authenticated")
+	az.AddAsync(func() ([]string, error) {
+		return []string{"use.Account.Member", "auth.Authenticated"}, nil
+	})
+	if az.Len() != 2 {
+		t.Fatalf("should have 2 roles, got %d", az.Len())
+	}
+	if !az.Contains("use.Account.Member") || az.Contains("use.Account.Admin") {
+		t.Fatal("should only contain added roles")
+	}
+}
+
+func Test_GettersReturnCopies(t *testing.T) {
+	roles := Rbac.AllRoles()
+	roles[0] = "mutated"
+	if Rbac.AllRoles()[0] != "auth.Authenticated" {
+		t.Fatal("AllRoles should return a copy")
+	}
+	permissions := Rbac.AllPermissions()
+	permissions[0] = "mutated"
+	if Rbac.AllPermissions()[0] != "create" {
+		t.Fatal("AllPermissions should return a copy")
+	}
+	granting := Rbac.RolesWithPermission("get")
+	granting[0] = "mutated"
+	if Rbac.RolesWithPermission("get")[0] != "use.Account.Admin" {
+		t.Fatal("RolesWithPermission should return a copy")
+	}
+	if len(Rbac.RolesWithPermission("unknown")) != 0 {
+		t.Fatal("RolesWithPermission should be empty for an unknown permission")
+	}
+	forPermission := Rbac.RolesForPermission("get")
+	forPermission[0] = "mutated"
+	if !reflect.DeepEqual(Rbac.RolesForPermission("get"), Rbac.RolesWithPermission("get")) {
+		t.Fatal("RolesForPermission should return a copy of RolesWithPermission")
+	}
+	if unknown := Rbac.RolesForPermission("unknown"); unknown == nil || len(unknown) != 0 {
+		t.Fatal("RolesForPermission should be empty for an unknown permission")
+	}
+	rolePermissions, err := Rbac.PermissionsForRole("use.Account.Member")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rolePermissions[0] = "mutated"
+	if rolePermissions, _ = Rbac.PermissionsForRole("use.Account.Member"); rolePermissions[0] != "get" {
+		t.Fatal("PermissionsForRole should return a copy")
+	}
+	if _, err := Rbac.PermissionsForRole("use.Account.Unknown"); err == nil {
+		t.Fatal("PermissionsForRole should error for an unknown role")
+	}
+}
+
+func Test_ConcurrentGetters(t *testing.T) {
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			roles := Rbac.AllRoles()
+			roles[0] = "mutated"
+			Rbac.AllPermissions()
+			Rbac.RolesWithPermission("get")
+			Rbac.PermissionsForRole("use.Account.Admin")
+			Rbac.ChainNames()
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_PermissionsAdded(t *testing.T) {
+	added := Rbac.PermissionsAdded([]string{"use.Account.Member", "unknown"}, "use.Account.Admin")
+	if len(added) != 2 || added[0] != "delete" || added[1] != "update" {
+		t.Fatalf("should add delete and update, got %v", added)
+	}
+	if added := Rbac.PermissionsAdded(nil, "unknown"); added == nil || len(added) != 0 {
+		t.Fatal("unknown candidate should add nothing")
+	}
+}
+
+func Test_WouldExceed(t *testing.T) {
+	forbidden := []string{"delete", "billing.delete", "get"}
+	if got := Rbac.WouldExceed([]string{"auth.Authenticated"}, "use.Account.Admin", forbidden); !reflect.DeepEqual(got, []string{"delete", "get"}) {
+		t.Fatal("should return the forbidden permissions the candidate adds, got", got)
+	}
+	if got := Rbac.WouldExceed([]string{"use.Account.Member"}, "use.Account.Admin", forbidden); !reflect.DeepEqual(got, []string{"delete"}) {
+		t.Fatal("should ignore forbidden permissions the current roles already give, got", got)
+	}
+	if got := Rbac.WouldExceed(nil, "use.Account.Member", []string{"delete"}); len(got) != 0 {
+		t.Fatal("should be safe if the candidate gives no forbidden permission, got", got)
+	}
+}
+
+func Test_Authorizers(t *testing.T) {
+	authorizers := Rbac.Authorizers(map[string][]string{
+		"alice": {"use.Account.Admin"},
+		"bob":   {"use.Account.Member"},
+	})
+	if len(authorizers) != 2 {
+		t.Fatalf("should return an authorizer per subject, got %d", len(authorizers))
+	}
+	if !authorizers["alice"].HasPermission("delete") || authorizers["bob"].HasPermission("delete") {
+		t.Fatal("each authorizer should only have its subject's roles")
+	}
+	if authorizers["bob"].Subject() != "bob" {
+		t.Fatal("should set the subject")
+	}
+}
+
+func Test_ValidateRole(t *testing.T) {
+	if !Rbac.IsValidRole("use.Account.Admin") || Rbac.IsValidRole("use.Account.Owner") {
+		t.Fatal("should only accept existing roles")
+	}
+	tests := map[string]string{
+		"use.Account.Admin": "",
+		"use.Account.Owner": "chain use.Account has no role Owner",
+		"billing.Admin":     `role "billing.Admin" has an unknown chain`,
+		"Admin":             `role "Admin" is not in the format {chainName}.{roleId}`,
+		"":                  `role "" is not in the format {chainName}.{roleId}`,
+	}
+	for role, want := range tests {
+		err := Rbac.ValidateRole(role)
+		if (want == "" && err != nil) || (want != "" && (err == nil || err.Error() != want)) {
+			t.Fatalf("%q: expected %q, got %v", role, want, err)
+		}
+	}
+}
+
+func Test_ValidateAssignments(t *testing.T) {
+	invalid := Rbac.ValidateAssignments(map[string][]string{
+		"alice": {"use.Account.Admin", "auth.Authenticated"},
+		"bob":   {"use.Account.Owner", "auth.Authenticated", "Admin"},
+		"carol": {},
+	})
+	if !reflect.DeepEqual(invalid, map[string][]string{"bob": {"use.Account.Owner", "Admin"}}) {
+		t.Fatalf("should only report the invalid roles of bob, got %v", invalid)
+	}
+}
+
+func Test_ChainInfo(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("auth").Describe("Whether the caller is signed in").Add("Unauthenticated", []string{"list"}),
+		rbac.Chain("use.Account").Add("Member", []string{"get"}).Add("Admin", []string{"update"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := r.ChainInfo("auth")
+	if err != nil || info.Description != "Whether the caller is signed in" {
+		t.Fatalf("should return the description, got %+v, %v", info, err)
+	}
+	info, _ = r.ChainInfo("use.Account")
+	if info.Description != "" || !reflect.DeepEqual(info.Roles, []string{"use.Account.Member", "use.Account.Admin"}) {
+		t.Fatalf("should return the roles in order without a description, got %+v", info)
+	}
+	if _, err := r.ChainInfo("billing"); err == nil {
+		t.Fatal("should reject unknown chains")
+	}
+}
+
+func Test_IsTopRole(t *testing.T) {
+	tests := map[string]bool{
+		"use.Account.Admin":    true,
+		"use.Account.Member":   false,
+		"auth.Authenticated":   true,
+		"auth.Unauthenticated": false,
+		"use.Account.Owner":    false,
+	}
+	for role, want := range tests {
+		if Rbac.IsTopRole(role) != want {
+			t.Fatalf("%s: expected %v", role, want)
+		}
+	}
+}
+
+func Test_EachChain(t *testing.T) {
+	chains := []string{}
+	Rbac.EachChain(func(chain string, roles []*rbac.Role) bool {
+		for _, role := range roles {
+			chains = append(chains, chain+"."+role.Id+":"+strings.Join(role.Permissions, ","))
+		}
+		roles[0].Permissions[0] = "mutated"
+		return true
+	})
+	want := []string{"auth.Unauthenticated:list", "auth.Authenticated:create,list", "use.Account.Member:get", "use.Account.Admin:delete,get,update"}
+	if !reflect.DeepEqual(chains, want) {
+		t.Fatalf("should visit the ordered roles of every chain, got %v", chains)
+	}
+	if Rbac.EffectivePermissionsForRole("auth.Unauthenticated")[0] != "list" {
+		t.Fatal("should pass copies of the roles")
+	}
+	visited := 0
+	Rbac.EachChain(func(chain string, roles []*rbac.Role) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Fatal("should stop when f returns false")
+	}
+}
+
+func Test_NewRbacErrors(t *testing.T) {
+	_, err := rbac.NewRbac(
+		rbac.Chain("auth").Add("Member", []string{"list"}).Add("Member", []string{"get"}),
+		rbac.Chain("use.Account").AddIndependent("Member", []string{""}).Add("Admin", []string{"update"}).Add("Admin", []string{"delete"}),
+	)
+	want := "duplicate role auth.Member\nrole use.Account.Member has an empty permission\nduplicate role use.Account.Admin"
+	if err == nil || err.Error() != want {
+		t.Fatalf("should report every problem, got %v", err)
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 3 {
+		t.Fatal("should unwrap to the individual errors")
+	}
+	_, err = rbac.NewRbac(rbac.Chain("auth"), rbac.Chain("auth"), rbac.Chain("ops"), rbac.Chain("ops"))
+	duplicate := &rbac.DuplicateChainError{}
+	if err == nil || err.Error() != "duplicate chain auth\nduplicate chain ops" || !errors.As(err, &duplicate) {
+		t.Fatalf("should report every duplicate chain, got %v", err)
+	}
+}
+
+func Test_Range(t *testing.T) {
+	pairs := []string{}
+	Rbac.Range(func(role, permission string) bool {
+		pairs = append(pairs, role+":"+permission)
+		return true
+	})
+	want := []string{
+		"auth.Authenticated:create", "auth.Authenticated:list", "auth.Unauthenticated:list",
+		"use.Account.Admin:delete", "use.Account.Admin:get", "use.Account.Admin:update", "use.Account.Member:get",
+	}
+	if !reflect.DeepEqual(pairs, want) {
+		t.Fatalf("should visit every pair in order, got %v", pairs)
+	}
+	visited := 0
+	Rbac.Range(func(role, permission string) bool {
+		visited++
+		return visited < 3
+	})
+	if visited != 3 {
+		t.Fatalf("should stop when f returns false, visited %d", visited)
+	}
+}
+
+func Test_UniversalPermissions(t *testing.T) {
+	if universal := Rbac.UniversalPermissions(); len(universal) != 0 {
+		t.Fatalf("should have no universal permissions, got %v", universal)
+	}
+	r, err := rbac.NewRbac(
+		rbac.Chain("auth").Add("Unauthenticated", []string{"list", "health"}).Add("Authenticated", []string{"create"}),
+		rbac.Chain("use.Account").Add("Member", []string{"list", "health", "get"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if universal := r.UniversalPermissions(); !reflect.DeepEqual(universal, []string{"health", "list"}) {
+		t.Fatalf("should return the permissions of every role, got %v", universal)
+	}
+}
+
+func Test_EmptyPermission(t *testing.T) {
+	if Rbac.Authorizer("use.Account.Admin", "auth.Authenticated").HasPermission("") {
+		t.Fatal("should deny the empty permission")
+	}
+	_, err := rbac.NewRbac(rbac.Chain("auth").Add("Unauthenticated", []string{"list", ""}))
+	if err == nil || err.Error() != "role auth.Unauthenticated has an empty permission" {
+		t.Fatalf("should reject empty permissions, got %v", err)
+	}
+	_, err = rbac.NewRbac(rbac.Chain("auth").Add("Unauthenticated", []string{"list"}).AddAllExcept("Admin", []string{""}))
+	if err == nil || err.Error() != "role auth.Admin has an empty permission" {
+		t.Fatalf("should reject empty exclusions, got %v", err)
+	}
+	_, err = rbac.NewRbac(rbac.Chain("auth").Add("Unauthenticated", []string{"list"}).AddConditional("Unauthenticated", "", func(map[string]any) bool { return true }))
+	if err == nil || err.Error() != "role auth.Unauthenticated has an empty conditional permission" {
+		t.Fatalf("should reject empty conditional permissions, got %v", err)
+	}
+}
+
+func Test_Warnings(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("auth").Add("Unauthenticated", []string{}).Add("Authenticated", []string{"list", "create"}),
+		rbac.Chain("use.Account").Add("Member", []string{"list", "get"}).Add("Admin", []string{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"role auth.Unauthenticated gives no permissions",
+		"role use.Account.Admin gives the same permissions as use.Account.Member",
+		`permission "create" is only given by role auth.Authenticated`,
+	}
+	if warnings := r.Warnings(); !reflect.DeepEqual(warnings, want) {
+		t.Fatalf("unexpected warnings %q", warnings)
+	}
+}
+
+func Test_AddSuperAdmin(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("use.Account").Add("Member", []string{"get"}).Add("Admin", []string{"update"}).AddSuperAdmin("Root").Add("Owner", []string{"delete"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := r.Authorizer("use.Account.Root")
+	if !root.HasPermission("update") || !root.HasPermission("added.later") || root.HasPermission("") {
+		t.Fatal("should give every non-empty permission")
+	}
+	if owner := r.Authorizer("use.Account.Owner"); !owner.HasPermission("update") || owner.HasPermission("added.later") {
+		t.Fatal("roles after the super admin should extend the role before it")
+	}
+	if permissions := root.Permissions(); !reflect.DeepEqual(permissions, []string{"delete", "get", "update"}) {
+		t.Fatalf("should list every known permission, got %v", permissions)
+	}
+	if granting := root.WhoGrants("added.later"); !reflect.DeepEqual(granting, []string{"use.Account.Root"}) {
+		t.Fatalf("should grant unknown permissions, got %v", granting)
+	}
+	if roles := r.RolesWithPermission("delete"); !reflect.DeepEqual(roles, []string{"use.Account.Owner", "use.Account.Root"}) {
+		t.Fatalf("should list the super admin for every permission, got %v", roles)
+	}
+}
+
+func Test_RoleIdChains(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("use.Account").Add("Member", []string{"get"}).Add("Admin", []string{"update"}),
+		rbac.Chain("billing").Add("Admin", []string{"refund"}),
+		rbac.Chain("auth").Add("Authenticated", []string{"list"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chains := r.RoleIdChains("Admin"); !reflect.DeepEqual(chains, []string{"billing", "use.Account"}) {
+		t.Fatalf("should list the chains defining Admin, got %v", chains)
+	}
+	if chains := r.RoleIdChains("Owner"); len(chains) != 0 {
+		t.Fatalf("should be empty for unused ids, got %v", chains)
+	}
+}
+
+func Test_RoleDelta(t *testing.T) {
+	tests := map[string][]string{
+		"use.Account.Member": {"get"},
+		"use.Account.Admin":  {"delete", "update"},
+		"auth.Authenticated": {"create"},
+		"use.Account.Owner":  {},
+	}
+	for role, want := range tests {
+		if delta := Rbac.RoleDelta(role); !reflect.DeepEqual(delta, want) {
+			t.Fatalf("%s: expected %v, got %v", role, want, delta)
+		}
+	}
+}
+
+func Test_RolePermissionDiff(t *testing.T) {
+	onlyA, onlyB := Rbac.RolePermissionDiff("use.Account.Admin", "auth.Authenticated")
+	if !reflect.DeepEqual(onlyA, []string{"delete", "get", "update"}) || !reflect.DeepEqual(onlyB, []string{"create", "list"}) {
+		t.Fatalf("unexpected diff %v %v", onlyA, onlyB)
+	}
+	onlyA, onlyB = Rbac.RolePermissionDiff("use.Account.Owner", "use.Account.Member")
+	if !reflect.DeepEqual(onlyA, []string{}) || !reflect.DeepEqual(onlyB, []string{"get"}) {
+		t.Fatalf("unknown roles should give nothing, got %v %v", onlyA, onlyB)
+	}
+}
+
+func Test_EquivalentTo(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("use.Account").Add("Member", []string{"get"}).Add("Admin", []string{"update"}),
+		rbac.Chain("legacy").AddIndependent("Reader", []string{"get"}).AddIndependent("Writer", []string{"update"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrated := r.Authorizer()
+	migrated.AddAsync(func() ([]string, error) {
+		return []string{"use.Account.Admin"}, nil
+	})
+	if !r.Authorizer("legacy.Reader", "legacy.Writer").EquivalentTo(migrated) {
+		t.Fatal("should be equivalent with the same permissions")
+	}
+	if r.Authorizer("legacy.Reader").EquivalentTo(migrated) {
+		t.Fatal("should not be equivalent with different permissions")
+	}
+}
+
+func Test_EachPermission(t *testing.T) {
+	permissions := []string{}
+	Rbac.EachPermission(func(permission string) bool {
+		permissions = append(permissions, permission)
+		return true
+	})
+	if !reflect.DeepEqual(permissions, Rbac.AllPermissions()) {
+		t.Fatalf("should visit the same permissions as AllPermissions, got %v", permissions)
+	}
+	visited := 0
+	Rbac.EachPermission(func(permission string) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Fatalf("should stop when f returns false, visited %d", visited)
+	}
+}
+
+func Test_PermissionRoleMap(t *testing.T) {
+	permissionRoleMap := Rbac.PermissionRoleMap()
+	if len(permissionRoleMap) != 5 {
+		t.Fatalf("should have all 5 permissions, got %v", permissionRoleMap)
+	}
+	if roles := permissionRoleMap["get"]; len(roles) != 2 || roles[0] != "use.Account.Admin" || roles[1] != "use.Account.Member" {
+		t.Fatalf("should list sorted roles giving get, got %v", roles)
+	}
+	permissionRoleMap["get"][0] = "mutated"
+	delete(permissionRoleMap, "list")
+	if again := Rbac.PermissionRoleMap(); again["get"][0] != "use.Account.Admin" || len(again) != 5 {
+		t.Fatal("should return a copy")
+	}
+}
+
+func Test_AddAsyncPanic(t *testing.T) {
+	az := Rbac.Authorizer()
+	az.AddAsync(func() ([]string, error) {
+		panic("connection reset")
+	})
+	err := az.Err()
+	if err == nil || err.Error() != "async role addition panicked: connection reset" {
+		t.Fatalf("should report the panic, got %v", err)
+	}
+}
+
+func Test_SortRolesBySeniority(t *testing.T) {
+	sorted := Rbac.SortRolesBySeniority([]string{
+		"unknown.Role",
+		"use.Account.Admin",
+		"auth.Authenticated",
+		"use.Account.Member",
+		"auth.Unauthenticated",
+	})
+	want := []string{
+		"auth.Unauthenticated",
+		"use.Account.Member",
+		"auth.Authenticated",
+		"use.Account.Admin",
+		"unknown.Role",
+	}
+	if !reflect.DeepEqual(sorted, want) {
+		t.Fatalf("expected %v, got %v", want, sorted)
+	}
+}
+
+func Test_CheckPermissionDefined(t *testing.T) {
+	az := Rbac.Authorizer("use.Account.Member")
+	if granted, defined := az.CheckPermissionDefined("get"); !granted || !defined {
+		t.Fatal("get should be granted and defined")
+	}
+	if granted, defined := az.CheckPermissionDefined("delete"); granted || !defined {
+		t.Fatal("delete should be defined but not granted")
+	}
+	if granted, defined := az.CheckPermissionDefined("unknown"); granted || defined {
+		t.Fatal("unknown should be neither granted nor defined")
+	}
+}
+
+func Test_WaitDuration(t *testing.T) {
+	az := Rbac.Authorizer("auth.Authenticated")
+	az.HasPermission("create")
+	if az.HadAsync() || az.WaitDuration() != 0 {
+		t.Fatal("should not report async loading")
+	}
+	az.AddAsync(func() ([]string, error) {
+		time.Sleep(20 * time.Millisecond)
+		return nil, nil
+	})
+	az.HasPermission("create")
+	if !az.HadAsync() {
+		t.Fatal("should report async loading")
+	}
+	if az.WaitDuration() < 10*time.Millisecond {
+		t.Fatalf("should record the wait, got %s", az.WaitDuration())
+	}
+}
+
+func Test_AddIndependent(t *testing.T) {
+	chain := rbac.Chain("team")
+	chain.Add("Viewer", []string{"view"})
+	chain.AddIndependent("Billing", []string{"invoice"})
+	chain.Add("Editor", []string{"edit"})
+	r, err := rbac.NewRbac(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if permissions, _ := r.PermissionsForRole("team.Billing"); !reflect.DeepEqual(permissions, []string{"invoice"}) {
+		t.Fatalf("independent role should not inherit, got %v", permissions)
+	}
+	if permissions, _ := r.PermissionsForRole("team.Editor"); !reflect.DeepEqual(permissions, []string{"edit", "view"}) {
+		t.Fatalf("extending role should skip the independent role, got %v", permissions)
+	}
+}
+
+func Test_ChainsWithPermission(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("use.Account").Add("Member", []string{"get"}),
+		rbac.Chain("use").Add("Project.Viewer", []string{"get"}),
+		rbac.Chain("auth").Add("Member", []string{"list"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chains := r.ChainsWithPermission("get"); !reflect.DeepEqual(chains, []string{"use", "use.Account"}) {
+		t.Fatalf("should split dotted chain names correctly, got %v", chains)
+	}
+	if chains := r.ChainsWithPermission("unknown"); chains == nil || len(chains) != 0 {
+		t.Fatal("should be empty for an unknown permission")
+	}
+}
+
+func Test_HasPermissionOrRole(t *testing.T) {
+	member := Rbac.Authorizer("use.Account.Member")
+	if !member.HasPermissionOrRole("get", "use.Account.Admin") {
+		t.Fatal("should pass with the permission")
+	}
+	if member.HasPermissionOrRole("delete", "use.Account.Admin") {
+		t.Fatal("should fail without permission or role")
+	}
+	if !Rbac.Authorizer("auth.Unauthenticated").HasPermissionOrRole("delete", "auth.Unauthenticated") {
+		t.Fatal("should pass with the role")
+	}
+}
+
+func Test_HasAllAnyPermissions(t *testing.T) {
+	member := Rbac.Authorizer()
+	member.AddAsync(func() ([]string, error) { return []string{"use.Account.Member"}, nil })
+	if !member.HasAllPermissions("get") || member.HasAllPermissions("get", "delete") {
+		t.Fatal("should require every permission")
+	}
+	if !member.HasAnyPermission("delete", "get") || member.HasAnyPermission("delete") {
+		t.Fatal("should require at least one permission")
+	}
+	if !member.HasAllPermissions() || member.HasAnyPermission() {
+		t.Fatal("should pass all and fail any without permissions")
+	}
+}
+
+func Test_RoleExtends(t *testing.T) {
+	if parent, ok := Rbac.RoleExtends("use.Account.Admin"); !ok || parent != "use.Account.Member" {
+		t.Fatalf("admin should extend member, got %s", parent)
+	}
+	if _, ok := Rbac.RoleExtends("use.Account.Member"); ok {
+		t.Fatal("first role should not extend anything")
+	}
+
+	chain := rbac.Chain("team").Add("Viewer", nil).AddIndependent("Billing", nil).Add("Editor", nil)
+	r, err := rbac.NewRbac(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent, _ := r.RoleExtends("team.Editor"); parent != "team.Viewer" {
+		t.Fatalf("editor should skip the independent role, got %s", parent)
+	}
+	if _, ok := r.RoleExtends("team.Billing"); ok {
+		t.Fatal("independent role should not extend anything")
+	}
+}
+
+func Test_HasPermissionOr(t *testing.T) {
+	member := Rbac.Authorizer("use.Account.Member")
+	if !member.HasPermissionOr("not.rolled.out", true) || member.HasPermissionOr("not.rolled.out", false) {
+		t.Fatal("should return the default for an undefined permission")
+	}
+	if member.HasPermissionOr("delete", true) || !member.HasPermissionOr("get", false) {
+		t.Fatal("should enforce a defined permission")
+	}
+}
+
+func Test_ConcurrentAddAndCheck(t *testing.T) {
+	az := Rbac.Authorizer()
+	wg := sync.WaitGroup{}
+	roles := []string{"auth.Authenticated", "use.Account.Member", "use.Account.Admin"}
+	permissions := []string{"create", "get", "delete"}
+	for i := range roles {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			az.Add(roles[i])
+			if !az.HasPermission(permissions[i]) {
+				t.Errorf("%s should be visible to a check after Add returned", roles[i])
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			az.HasPermission(permissions[(i+1)%len(permissions)])
+			az.HasRole(roles[(i+1)%len(roles)])
+		}()
+	}
+	wg.Wait()
+	for _, permission := range permissions {
+		if !az.HasPermission(permission) {
+			t.Fatalf("should have %s after all adds", permission)
+		}
+	}
+}
+
+func Test_DecidingRoleStable(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("a").Add("Member", []string{"get"}),
+		rbac.Chain("b").Add("Member", []string{"get"}),
+		rbac.Chain("c").Add("Member", []string{"get"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		az := r.Authorizer("c.Member", "b.Member", "a.Member").TrackUsage()
+		az.HasPermission("get")
+		if used := az.UsedRoles(); !reflect.DeepEqual(used, []string{"a.Member"}) {
+			t.Fatal("should record the same deciding role on every run, got", used)
+		}
+	}
+}
+
+func Test_UsedRoles(t *testing.T) {
+	az := Rbac.Authorizer("auth.Authenticated", "use.Account.Member", "use.Account.Admin").TrackUsage()
+	az.HasPermission("get")
+	az.HasPermission("create")
+	az.HasPermission("unknown")
+	if used := az.UsedRoles(); !reflect.DeepEqual(used, []string{"auth.Authenticated", "use.Account.Member"}) {
+		t.Fatalf("should record the least senior granting roles, got %v", used)
+	}
+}
+
+func Test_ChainFromRoles(t *testing.T) {
+	chain := rbac.ChainFromRoles("team", []*rbac.Role{
+		{Id: "Viewer", Permissions: []string{"view"}},
+		{Id: "Editor", Permissions: []string{"edit"}},
+	})
+	chain.Add("Owner", []string{"delete"})
+	r, err := rbac.NewRbac(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if permissions, _ := r.PermissionsForRole("team.Editor"); !reflect.DeepEqual(permissions, []string{"edit"}) {
+		t.Fatalf("should not auto-extend the given roles, got %v", permissions)
+	}
+	if permissions, _ := r.PermissionsForRole("team.Owner"); !reflect.DeepEqual(permissions, []string{"delete", "edit"}) {
+		t.Fatalf("added role should extend the last given role, got %v", permissions)
+	}
+}
+
+func Test_WhoGrants(t *testing.T) {
+	az := Rbac.Authorizer("use.Account.Admin", "use.Account.Member", "auth.Authenticated")
+	if granting := az.WhoGrants("get"); !reflect.DeepEqual(granting, []string{"use.Account.Admin", "use.Account.Member"}) {
+		t.Fatalf("should list every granting role, got %v", granting)
+	}
+	if granting := az.WhoGrants("unknown"); granting == nil || len(granting) != 0 {
+		t.Fatal("should be empty when no role grants it")
+	}
+}
+
+func Test_AddAsyncInvalidRoles(t *testing.T) {
+	az := Rbac.Authorizer()
+	az.AddAsync(func() ([]string, error) {
+		return []string{"use.Account.Member", "use.Account.Owner"}, nil
+	})
+	if !az.HasRole("use.Account.Member") || !az.HasPermission("get") {
+		t.Fatal("should add the valid roles")
+	}
+	if az.HasRole("use.Account.Owner") || !reflect.DeepEqual(az.Roles(), []string{"use.Account.Member"}) {
+		t.Fatal("should not add the invalid roles, got", az.Roles())
+	}
+	if err := az.Err(); err == nil || err.Error() != "role use.Account.Owner not allowed" {
+		t.Fatalf("should report the invalid roles, got %v", err)
+	}
+
+	validated := Rbac.Authorizer()
+	if err := validated.AddValidated("use.Account.Admin", "use.Account.Owner", "typo"); err == nil || err.Error() != "role use.Account.Owner not allowed; role typo not allowed" {
+		t.Fatalf("should return the invalid roles, got %v", err)
+	}
+	if !reflect.DeepEqual(validated.Roles(), []string{"use.Account.Admin"}) {
+		t.Fatal("should only add the valid roles, got", validated.Roles())
+	}
+	if validated.AddValidated("use.Account.Member") != nil {
+		t.Fatal("should accept valid roles")
+	}
+}
+
+func Test_Remove(t *testing.T) {
+	az := Rbac.Authorizer("use.Account.Member")
+	release := make(chan struct{})
+	az.AddAsync(func() ([]string, error) {
+		<-release
+		return []string{"use.Account.Admin"}, nil
+	})
+	go close(release)
+	az.Remove("use.Account.Admin")
+	if az.HasRole("use.Account.Admin") || az.HasPermission("delete") {
+		t.Fatal("should remove a role added by a pending async addition")
+	}
+	if !az.HasRole("use.Account.Member") || !az.HasPermission("get") {
+		t.Fatal("should keep the other roles")
+	}
+	az.Remove("use.Account.Member", "unknown")
+	if az.HasPermission("get") || az.Len() != 0 {
+		t.Fatal("should remove every given role")
+	}
+}
+
+func Test_AddAsyncCtx(t *testing.T) {
+	az := Rbac.Authorizer()
+	az.AddAsyncCtx(context.Background(), func(ctx context.Context) ([]string, error) {
+		return []string{"use.Account.Member"}, nil
+	})
+	if !az.HasPermission("get") || az.Err() != nil {
+		t.Fatal("should add the roles of a resolver that returns in time")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	release := make(chan struct{})
+	returned := make(chan struct{})
+	hung := Rbac.Authorizer()
+	hung.AddAsyncCtx(ctx, func(ctx context.Context) ([]string, error) {
+		defer close(returned)
+		<-release
+		return []string{"use.Account.Admin"}, nil
+	})
+	if err := hung.Err(); err == nil || err.Error() != "role resolution cancelled: context deadline exceeded" {
+		t.Fatalf("should stop waiting and report the cancellation, got %v", err)
+	}
+	close(release)
+	<-returned
+	if hung.HasPermission("delete") {
+		t.Fatal("should discard roles arriving after the context is done")
+	}
+
+	panicking := Rbac.Authorizer()
+	panicking.AddAsyncCtx(context.Background(), func(ctx context.Context) ([]string, error) {
+		panic("boom")
+	})
+	if err := panicking.Err(); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("should report a panic of the resolver, got %v", err)
+	}
+}
+
+func Test_AddAsyncChan(t *testing.T) {
+	az := Rbac.Authorizer()
+	release := make(chan struct{})
+	az.AddAsync(func() ([]string, error) {
+		<-release
+		return []string{"use.Account.Admin"}, nil
+	})
+	primary := az.AddAsyncChan(func() ([]string, error) {
+		return []string{"use.Account.Member"}, nil
+	})
+	if err := <-primary; err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-primary; ok {
+		t.Fatal("channel should be closed")
+	}
+	close(release)
+
+	failing := az.AddAsyncChan(func() ([]string, error) {
+		return []string{"use.Account.Owner"}, nil
+	})
+	if err := <-failing; err == nil || err.Error() != "role use.Account.Owner not allowed" {
+		t.Fatalf("should receive the addition's errors, got %v", err)
+	}
+	if err := az.Err(); err == nil || err.Error() != "role use.Account.Owner not allowed" {
+		t.Fatalf("Err should still report all errors, got %v", err)
+	}
+}
+
+func BenchmarkPermissionsForRole(b *testing.B) {
+	permissions := make([]string, 100)
+	for i := range permissions {
+		permissions[i] = fmt.Sprintf("perm%d", i)
+	}
+	r, err := rbac.NewRbac(rbac.Chain("bench").Add("Member", permissions))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.PermissionsForRole("bench.Member")
+	}
+}
+
+func BenchmarkPermissions(b *testing.B) {
+	chain := rbac.Chain("bench")
+	roles := make([]string, 50)
+	for i := range roles {
+		permissions := make([]string, 500)
+		for j := range permissions {
+			permissions[j] = fmt.Sprintf("perm%d", (i*37+j)%5000)
+		}
+		chain.AddIndependent(fmt.Sprintf("Role%d", i), permissions)
+		roles[i] = fmt.Sprintf("bench.Role%d", i)
+	}
+	r, err := rbac.NewRbac(chain)
+	if err != nil {
+		b.Fatal(err)
+	}
+	az := r.Authorizer(roles...)
+	b.Run("bitset", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			az.Permissions()
+		}
+	})
+	b.Run("map", func(b *testing.B) {
+		roleToPermissions := map[string][]string{}
+		for _, role := range roles {
+			roleToPermissions[role], _ = r.PermissionsForRole(role)
+		}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			permissionSet := map[string]bool{}
+			for _, role := range roles {
+				for _, permission := range roleToPermissions[role] {
+					permissionSet[permission] = true
+				}
+			}
+			permissions := make([]string, 0, len(permissionSet))
+			for permission := range permissionSet {
+				permissions = append(permissions, permission)
+			}
+			sort.Strings(permissions)
+		}
+	})
+}
+
+func BenchmarkHasRole(b *testing.B) {
+	b.Run("sync", func(b *testing.B) {
+		az := Rbac.Authorizer("use.Account.Member")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			az.HasRole("use.Account.Member")
+		}
+	})
+	b.Run("async", func(b *testing.B) {
+		az := Rbac.Authorizer()
+		az.AddAsync(func() ([]string, error) {
+			return []string{"use.Account.Member"}, nil
+		})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			az.HasRole("use.Account.Member")
+		}
+	})
+}
+
+func Test_HasPermissionAudited(t *testing.T) {
+	az := Rbac.Authorizer("use.Account.Member")
+	audited := []bool{}
+	audit := func(granted bool) {
+		audited = append(audited, granted)
+	}
+	if !az.HasPermissionAudited("get", audit) || az.HasPermissionAudited("delete", audit) {
+		t.Fatal("should return the result of HasPermission")
+	}
+	if !reflect.DeepEqual(audited, []bool{true, false}) {
+		t.Fatal("should audit every decision, got", audited)
+	}
+}
+
+func Test_AuthorizerRedundantRoles(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("auth").Add("Unauthenticated", []string{"list"}).Add("Authenticated", []string{"create"}),
+		rbac.Chain("use.Account").Add("Member", []string{"get"}).AddIndependent("Viewer", []string{"get"}).AddSuperAdmin("Root"),
+		rbac.Chain("ops").AddSuperAdmin("Root"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	az := r.Authorizer("auth.Unauthenticated", "auth.Authenticated", "use.Account.Member", "use.Account.Viewer")
+	if got := az.RedundantRoles(); !reflect.DeepEqual(got, []string{"auth.Unauthenticated", "use.Account.Member"}) {
+		t.Fatal("should list the covered roles while keeping one of equal roles, got", got)
+	}
+	if got := r.Authorizer("use.Account.Root", "auth.Authenticated").RedundantRoles(); !reflect.DeepEqual(got, []string{"auth.Authenticated"}) {
+		t.Fatal("a super admin should cover every role, got", got)
+	}
+	if got := r.Authorizer("ops.Root", "use.Account.Root").RedundantRoles(); !reflect.DeepEqual(got, []string{"ops.Root"}) {
+		t.Fatal("a super admin should only be covered by another super admin, got", got)
+	}
+}
+
+func Test_RolesGrant(t *testing.T) {
+	if !Rbac.RolesGrant("get", "auth.Authenticated", "use.Account.Member") || !Rbac.RolesGrant("get", "use.Account.Admin") {
+		t.Fatal("should grant a permission any of the roles gives")
+	}
+	if Rbac.RolesGrant("delete", "use.Account.Member", "unknown") || Rbac.RolesGrant("get") || Rbac.RolesGrant("", "use.Account.Admin") {
+		t.Fatal("should not grant a permission none of the roles gives")
+	}
+}
+
+func BenchmarkRolesGrant(b *testing.B) {
+	b.Run("rbac", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Rbac.RolesGrant("delete", "auth.Authenticated", "use.Account.Admin")
+		}
+	})
+	b.Run("authorizer", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Rbac.Authorizer("auth.Authenticated", "use.Account.Admin").HasPermission("delete")
+		}
+	})
+}
+
+func BenchmarkConcurrentAddAsync(b *testing.B) {
+	for _, failing := range []bool{false, true} {
+		b.Run(fmt.Sprintf("failing=%v", failing), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				az := Rbac.Authorizer()
+				for j := 0; j < 64; j++ {
+					az.AddAsync(func() ([]string, error) {
+						if failing {
+							return nil, fmt.Errorf("source %d failed", j)
+						}
+						return []string{"use.Account.Member"}, nil
+					})
+				}
+				az.Err()
+			}
+		})
+	}
+}
+
+func Test_AddGated(t *testing.T) {
+	enabled := atomic.Bool{}
+	chain := rbac.Chain("app")
+	chain.Add("Member", []string{"view"})
+	chain.AddGated("Beta", []string{"experiment"}, enabled.Load)
+	chain.Add("Admin", []string{"manage"})
+	r, err := rbac.NewRbac(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	beta := r.Authorizer("app.Beta")
+	if beta.HasPermission("experiment") || beta.HasPermission("view") {
+		t.Fatal("gated role should not count while disabled")
+	}
+	enabled.Store(true)
+	if !beta.HasPermission("experiment") || !beta.HasPermission("view") {
+		t.Fatal("gated role should count while enabled")
+	}
+	if r.Authorizer("app.Admin").HasPermission("experiment") {
+		t.Fatal("later roles should not extend the gated role")
+	}
+	for _, on := range []bool{false, true} {
+		enabled.Store(on)
+		az := r.Authorizer("app.Beta")
+		permissions := az.Permissions()
+		for _, permission := range r.AllPermissions() {
+			if az.HasPermission(permission) != slices.Contains(permissions, permission) {
+				t.Fatalf("permissions should agree with HasPermission for %s while enabled is %v, got %v", permission, on, permissions)
+			}
+		}
+	}
+}
+
+func Test_UninitializedRbac(t *testing.T) {
+	for _, r := range []*rbac.Rbac{nil, {}} {
+		az := r.Authorizer("auth.Authenticated")
+		az.AddAsync(func() ([]string, error) {
+			return []string{"use.Account.Member"}, nil
+		})
+		if az.HasPermission("get") {
+			t.Fatal("should deny everything")
+		}
+		if err := az.Err(); err == nil || !strings.Contains(err.Error(), "rbac not initialized") {
+			t.Fatalf("should report the uninitialized rbac, got %v", err)
+		}
+		if az := r.AuthorizerFromHeader("auth.Authenticated"); az.HasRole("auth.Authenticated") {
+			t.Fatal("should drop header roles")
+		}
+	}
+}
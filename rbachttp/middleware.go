@@ -0,0 +1,70 @@
+package rbachttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+// Returns a middleware that builds an authorizer with the roles extract returns for every request and stores it
+// in the context of the passed on request, see FromContext. A request is rejected with 401 Unauthorized if
+// extract fails and with 500 Internal Server Error if a role does not exist or the authorizer reports an error,
+// so a broken role resolution never reaches the handlers as an ordinary unauthorized request.
+func Middleware(r *rbac.Rbac, extract RoleSource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			roles, err := extract(req)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("unauthorized: %v", err), http.StatusUnauthorized)
+				return
+			}
+			a := r.Authorizer()
+			if err := a.AddValidated(roles...); err != nil {
+				http.Error(w, fmt.Sprintf("resolving roles: %v", err), http.StatusInternalServerError)
+				return
+			}
+			if err := a.Err(); err != nil {
+				http.Error(w, fmt.Sprintf("resolving roles: %v", err), http.StatusInternalServerError)
+				return
+			}
+			next.ServeHTTP(w, req.WithContext(rbac.ContextWithAuthorizer(req.Context(), a)))
+		})
+	}
+}
+
+// Returns the authorizer stored in the context by Middleware or RequirePolicy and whether there is one.
+func FromContext(ctx context.Context) (*rbac.Authorizer, bool) {
+	return rbac.AuthorizerFromContext(ctx)
+}
+
+// Returns a middleware that only passes requests on whose context authorizer has the permission, to be used
+// after Middleware. A request without an authorizer is rejected with 401 Unauthorized, one whose authorizer
+// reports an error with 500 Internal Server Error and one lacking the permission is denied, by default with
+// 403 Forbidden.
+func RequirePermission(permission string, opts ...Option) func(http.Handler) http.Handler {
+	c := &config{denied: defaultDenied}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			a, ok := FromContext(req.Context())
+			if !ok {
+				http.Error(w, "unauthorized: no authorizer", http.StatusUnauthorized)
+				return
+			}
+			d := a.Evaluate(req.Context(), rbac.Request{Permission: permission})
+			if d.Err != nil {
+				http.Error(w, d.Reason, http.StatusInternalServerError)
+				return
+			}
+			if !d.Allowed {
+				c.denied(w, req, fmt.Sprintf("requires %s", permission))
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
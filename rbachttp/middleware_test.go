@@ -0,0 +1,44 @@
+package rbachttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/acudac-com/rbac-go/rbachttp"
+)
+
+func Test_Middleware(t *testing.T) {
+	r := newRbac(t)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, ok := rbachttp.FromContext(req.Context()); !ok {
+			t.Fatal("should pass the authorizer on")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := rbachttp.Middleware(r, headerRoles)(rbachttp.RequirePermission("write")(ok))
+	tests := map[string]struct {
+		code int
+		body string
+	}{
+		"docs.Writer":  {http.StatusNoContent, ""},
+		"docs.Reader":  {http.StatusForbidden, "forbidden: requires write\n"},
+		"":             {http.StatusUnauthorized, "unauthorized: missing roles\n"},
+		"docs.Unknown": {http.StatusInternalServerError, "resolving roles: role docs.Unknown not allowed\n"},
+	}
+	for roles, want := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+		req.Header.Set("X-Roles", roles)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want.code || rec.Body.String() != want.body {
+			t.Fatalf("%q: expected %d %q, got %d %q", roles, want.code, want.body, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	rbachttp.RequirePermission("write")(ok).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatal("should reject requests without an authorizer, got", rec.Code)
+	}
+}
@@ -0,0 +1,73 @@
+// Package rbachttp provides http middleware that authorizes requests with an rbac.
+package rbachttp
+
+import (
+	"net/http"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+// Returns the roles of a request, e.g. from a verified token.
+type RoleSource func(r *http.Request) ([]string, error)
+
+// Writes the response for a request that was denied for the given reason.
+type DeniedHandler func(w http.ResponseWriter, r *http.Request, reason string)
+
+// An option to configure a middleware.
+type Option func(*config)
+
+// The configuration of a middleware.
+type config struct {
+	// Writes the response of denied requests.
+	denied DeniedHandler
+}
+
+// Returns an option that writes the response of denied requests with h instead of a plain 403 Forbidden.
+func WithDeniedHandler(h DeniedHandler) Option {
+	return func(c *config) {
+		c.denied = h
+	}
+}
+
+// Writes a 403 Forbidden with the reason.
+func defaultDenied(w http.ResponseWriter, r *http.Request, reason string) {
+	http.Error(w, "forbidden: "+reason, http.StatusForbidden)
+}
+
+// Returns a middleware that only passes requests on whose authorizer satisfies the policy, e.g.
+// AllOf(Perm("read"), AnyOf(Perm("write"), Perm("admin"))). The authorizer gets the roles of every source, or is
+// taken from the request context if there are no sources, and is stored in the context of the passed on request.
+// Denied requests, including requests whose roles could not be resolved, get the policy and the checked permissions
+// that were missing as reason.
+func RequirePolicy(r *rbac.Rbac, p rbac.Policy, sources []RoleSource, opts ...Option) func(http.Handler) http.Handler {
+	c := &config{denied: defaultDenied}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			a := authorizer(r, req, sources)
+			if d := a.Evaluate(req.Context(), rbac.Request{Policy: p}); !d.Allowed {
+				c.denied(w, req, d.Reason)
+				return
+			}
+			next.ServeHTTP(w, req.WithContext(rbac.ContextWithAuthorizer(req.Context(), a)))
+		})
+	}
+}
+
+// Returns an authorizer with the roles of the sources, or the authorizer of the request context without sources.
+func authorizer(r *rbac.Rbac, req *http.Request, sources []RoleSource) *rbac.Authorizer {
+	if len(sources) == 0 {
+		if a, ok := rbac.AuthorizerFromContext(req.Context()); ok {
+			return a
+		}
+	}
+	a := r.Authorizer()
+	for _, source := range sources {
+		a.AddAsync(func() ([]string, error) {
+			return source(req)
+		})
+	}
+	return a
+}
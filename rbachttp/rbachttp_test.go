@@ -0,0 +1,75 @@
+package rbachttp_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+	"github.com/acudac-com/rbac-go/rbachttp"
+)
+
+// Returns the roles of the X-Roles header.
+func headerRoles(r *http.Request) ([]string, error) {
+	if r.Header.Get("X-Roles") == "" {
+		return nil, fmt.Errorf("missing roles")
+	}
+	return strings.Split(r.Header.Get("X-Roles"), ","), nil
+}
+
+// Returns a new rbac for the tests.
+func newRbac(t *testing.T) *rbac.Rbac {
+	r, err := rbac.NewRbac(rbac.Chain("docs").Add("Reader", []string{"read"}).Add("Writer", []string{"write"}), rbac.Chain("ops").AddIndependent("Admin", []string{"admin"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func Test_RequirePolicy(t *testing.T) {
+	r := newRbac(t)
+	policy := rbac.AllOf(rbac.Perm("read"), rbac.AnyOf(rbac.Perm("write"), rbac.Perm("admin")))
+	handler := rbachttp.RequirePolicy(r, policy, []rbachttp.RoleSource{headerRoles})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, ok := rbac.AuthorizerFromContext(req.Context()); !ok {
+			t.Fatal("should pass the authorizer on")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	tests := map[string]struct {
+		code int
+		body string
+	}{
+		"docs.Writer":           {http.StatusNoContent, ""},
+		"docs.Reader,ops.Admin": {http.StatusNoContent, ""},
+		"docs.Reader":           {http.StatusForbidden, "forbidden: requires (read AND (write OR admin)), missing [write admin]\n"},
+		"":                      {http.StatusForbidden, "forbidden: resolving roles: missing roles\n"},
+	}
+	for roles, want := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+		req.Header.Set("X-Roles", roles)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want.code || rec.Body.String() != want.body {
+			t.Fatalf("%q: expected %d %q, got %d %q", roles, want.code, want.body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func Test_RequirePolicyDeniedHandler(t *testing.T) {
+	r := newRbac(t)
+	reasons := []string{}
+	denied := func(w http.ResponseWriter, req *http.Request, reason string) {
+		reasons = append(reasons, reason)
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+	handler := rbachttp.RequirePolicy(r, rbac.Perm("admin"), nil, rbachttp.WithDeniedHandler(denied))(http.NotFoundHandler())
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(rbac.ContextWithAuthorizer(req.Context(), r.Authorizer("docs.Writer")))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || len(reasons) != 1 || reasons[0] != "requires admin, missing [admin]" {
+		t.Fatalf("should use the denied handler with the context authorizer, got %d %v", rec.Code, reasons)
+	}
+}
@@ -0,0 +1,60 @@
+// Package rbactest provides test assertions for authorizers with readable failure messages, e.g.
+//
+//	rbactest.RequireGranted(t, az, "delete")
+//
+// fails with: expected permission "delete" to be granted; authorizer has roles [use.Account.Member] granting [get].
+package rbactest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+// Fails the test if the authorizer does not have the permission.
+func RequireGranted(t testing.TB, a *rbac.Authorizer, permission string) {
+	t.Helper()
+	if !a.HasPermission(permission) {
+		t.Fatalf("expected permission %q to be granted; authorizer has roles %v granting %v", permission, a.Roles(), a.Permissions())
+	}
+}
+
+// Fails the test if the authorizer has the permission.
+func RequireDenied(t testing.TB, a *rbac.Authorizer, permission string) {
+	t.Helper()
+	if a.HasPermission(permission) {
+		t.Fatalf("expected permission %q to be denied; granted by roles %v of roles %v", permission, a.WhoGrants(permission), a.Roles())
+	}
+}
+
+// Fails the test if the authorizer does not have all of the roles.
+func RequireRoles(t testing.TB, a *rbac.Authorizer, roles ...string) {
+	t.Helper()
+	missing := []string{}
+	for _, role := range roles {
+		if !a.HasRole(role) {
+			missing = append(missing, role)
+		}
+	}
+	if len(missing) > 0 {
+		t.Fatalf("expected roles %v; authorizer has roles %v", missing, a.Roles())
+	}
+}
+
+// Fails the test if a permission is not defined in the rbac, e.g. to assert that every permission the handlers
+// check exists so a misspelled permission fails at test time instead of silently denying in production. The
+// failure lists the error of Authorizer.CheckPermission, including a suggestion, for every unknown permission.
+func RequireDefined(t testing.TB, r *rbac.Rbac, permissions ...string) {
+	t.Helper()
+	a := r.Authorizer()
+	unknown := []string{}
+	for _, permission := range permissions {
+		if _, err := a.CheckPermission(permission); err != nil {
+			unknown = append(unknown, err.Error())
+		}
+	}
+	if len(unknown) > 0 {
+		t.Fatalf("expected defined permissions; %s", strings.Join(unknown, "; "))
+	}
+}
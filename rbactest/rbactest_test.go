@@ -0,0 +1,55 @@
+package rbactest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+	"github.com/acudac-com/rbac-go/rbactest"
+)
+
+// A test that records its failure instead of failing.
+type recorder struct {
+	testing.TB
+	failure string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.failure = fmt.Sprintf(format, args...)
+}
+
+func Test_Assertions(t *testing.T) {
+	r, err := rbac.NewRbac(rbac.Chain("use.Account").Add("Member", []string{"get"}).Add("Admin", []string{"delete"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	member := r.Authorizer("use.Account.Member")
+	rbactest.RequireGranted(t, member, "get")
+	rbactest.RequireDenied(t, member, "delete")
+	rbactest.RequireRoles(t, member, "use.Account.Member")
+	rbactest.RequireDefined(t, r, "get", "delete")
+
+	tests := map[string]func(tb testing.TB){
+		`expected permission "delete" to be granted; authorizer has roles [use.Account.Member] granting [get]`: func(tb testing.TB) {
+			rbactest.RequireGranted(tb, member, "delete")
+		},
+		`expected permission "get" to be denied; granted by roles [use.Account.Member] of roles [use.Account.Member]`: func(tb testing.TB) {
+			rbactest.RequireDenied(tb, member, "get")
+		},
+		`expected roles [use.Account.Admin]; authorizer has roles [use.Account.Member]`: func(tb testing.TB) {
+			rbactest.RequireRoles(tb, member, "use.Account.Member", "use.Account.Admin")
+		},
+		`expected defined permissions; unknown permission "gte", did you mean "get"?; unknown permission "update"`: func(tb testing.TB) {
+			rbactest.RequireDefined(tb, r, "get", "gte", "update")
+		},
+	}
+	for want, assert := range tests {
+		rec := &recorder{TB: t}
+		assert(rec)
+		if rec.failure != want {
+			t.Fatalf("expected failure %q, got %q", want, rec.failure)
+		}
+	}
+}
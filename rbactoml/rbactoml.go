@@ -0,0 +1,45 @@
+// Package rbactoml reads and writes the config of an rbac as TOML, so the rbac package does not depend on a TOML
+// library.
+package rbactoml
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+// Returns a new role-based access controller from the config of a TOML document as written by Write, e.g.
+//
+//	[[chains]]
+//	name = "use.Account"
+//
+//	[[chains.roles]]
+//	id = "Member"
+//	permissions = ["get"]
+//
+//	[[chains.roles]]
+//	id = "Admin"
+//	extends = "Member"
+//	permissions = ["update", "delete"]
+//
+// The config is built with Config.Build.
+func Load(r io.Reader) (*rbac.Rbac, error) {
+	c := rbac.Config{}
+	if _, err := toml.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("decoding toml: %w", err)
+	}
+	return c.Build()
+}
+
+// Writes the config of the rbac as a TOML document that Load reads back into an equal rbac.
+// See Rbac.Config for what the config covers.
+func Write(w io.Writer, r *rbac.Rbac) error {
+	c, err := r.Config()
+	if err != nil {
+		return err
+	}
+	return toml.NewEncoder(w).Encode(c)
+}
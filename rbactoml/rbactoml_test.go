@@ -0,0 +1,88 @@
+package rbactoml_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+	"github.com/acudac-com/rbac-go/rbactoml"
+)
+
+func Test_Write(t *testing.T) {
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("auth").Describe("Whether the caller is signed in").Add("Unauthenticated", []string{"list"}).Add("Authenticated", []string{"create"}),
+		rbac.Chain("use.Account").Realm("billing").Add("Member", []string{"get"}).AddIndependent("Auditor", []string{"audit"}).Add("Admin", []string{"update", "delete"}).AddSuperAdmin("Root"),
+		rbac.Chain("ops").AddAllExcept("Operator", []string{"delete"}),
+		rbac.Chain("team").Extends("use.Account").Add("Lead", []string{"invite"}),
+	}, rbac.WithPrivilegedRoles("auth.Authenticated"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := rbactoml.Write(buf, r); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := rbactoml.Load(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Equal(r) {
+		t.Fatal("should load an equal rbac")
+	}
+	if strings.Join(loaded.ChainNames(), " ") != "auth use.Account ops team" || loaded.RoleRealm("use.Account.Admin") != "billing" {
+		t.Fatal("should keep the chain order and realms, got", loaded.ChainNames())
+	}
+	if parent, _ := loaded.RoleExtends("use.Account.Admin"); parent != "use.Account.Member" {
+		t.Fatal("should keep the role order, got", parent)
+	}
+	if info, _ := loaded.ChainInfo("auth"); info.Description != "Whether the caller is signed in" {
+		t.Fatal("should keep descriptions")
+	}
+}
+
+func Test_Load(t *testing.T) {
+	r, err := rbactoml.Load(strings.NewReader(`
+[[chains]]
+name = "use.Account"
+
+[[chains.roles]]
+id = "Member"
+permissions = ["get"]
+
+[[chains.roles]]
+id = "Admin"
+extends = "Member"
+permissions = ["update"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(r.EffectivePermissionsForRole("use.Account.Admin"), " ") != "get update" {
+		t.Fatal("should extend the permissions of the extended role")
+	}
+	tests := map[string]string{
+		"": "no role chains provided",
+		"[[chains]]\nname = \"a\"\n[[chains.roles]]\nid = \"Admin\"\nextends = \"Member\"\npermissions = []": "role a.Admin extends unknown role Member",
+		"[[chains]]\nname = \"a\"\n[[chains.roles]]\nid = \"Member\"\npermissions = [\"\"]":                  "role a.Member has an empty permission",
+	}
+	for doc, want := range tests {
+		if _, err := rbactoml.Load(strings.NewReader(doc)); err == nil || err.Error() != want {
+			t.Fatalf("should return %q, got %v", want, err)
+		}
+	}
+	if _, err := rbactoml.Load(strings.NewReader("[[chains")); err == nil || !strings.HasPrefix(err.Error(), "decoding toml: ") {
+		t.Fatal("should reject invalid toml, got", err)
+	}
+}
+
+func Test_WriteFunctions(t *testing.T) {
+	gated, _ := rbac.NewRbac(rbac.Chain("a").AddGated("Beta", []string{"beta"}, func() bool { return true }))
+	if err := rbactoml.Write(&bytes.Buffer{}, gated); err == nil || err.Error() != "role a.Beta is gated and cannot be configured" {
+		t.Fatal("should reject gated roles, got", err)
+	}
+	conditional, _ := rbac.NewRbac(rbac.Chain("a").Add("Member", []string{"get"}).AddConditional("Member", "edit", func(map[string]any) bool { return true }))
+	if err := rbactoml.Write(&bytes.Buffer{}, conditional); err == nil || err.Error() != "role a.Member has a conditional permission edit that cannot be configured" {
+		t.Fatal("should reject conditional permissions, got", err)
+	}
+}
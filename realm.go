@@ -0,0 +1,61 @@
+package rbac
+
+import "fmt"
+
+// Assigns the chain to a realm, e.g. one product of a monolith, so checks can be restricted to the realm with
+// Rbac.InRealm. Chains without a realm belong to no realm.
+func (c *RoleChain) Realm(name string) *RoleChain {
+	c.realm = name
+	return c
+}
+
+// Returns an option that restricts an rbac to the chains of a realm.
+func inRealm(realm string) Option {
+	return func(o *options) {
+		o.realm = realm
+	}
+}
+
+// Builds a view of every realm from its chains with the same options. Role aliases, default and privileged roles
+// of other realms are left out of a view, but a chain may not extend a chain of another realm.
+func (r *Rbac) buildRealms(roleChains []*RoleChain, opts []Option) error {
+	r.realms = map[string]*Rbac{}
+	if r.config.realm != "" {
+		return nil
+	}
+	realmToChains := map[string][]*RoleChain{}
+	for _, chain := range roleChains {
+		if chain.realm != "" {
+			realmToChains[chain.realm] = append(realmToChains[chain.realm], chain)
+		}
+	}
+	for _, realm := range sortedKeys(realmToChains) {
+		view, err := NewRbacWithOptions(realmToChains[realm], append(opts[:len(opts):len(opts)], inRealm(realm))...)
+		if err != nil {
+			return fmt.Errorf("realm %s: %w", realm, err)
+		}
+		r.realms[realm] = view
+	}
+	return nil
+}
+
+// Returns a view restricted to the chains of the realm, whose checks only consider the roles and permissions of
+// those chains, so an identical permission name of another realm grants nothing. Roles keep their flattened
+// {chainName}.{roleId} names in the view and roles of other realms are unknown to it. Returns the rbac itself if
+// it is already the view of the realm and nil if no chain belongs to the realm.
+func (r *Rbac) InRealm(name string) *Rbac {
+	if r.config.realm == name {
+		return r
+	}
+	return r.realms[name]
+}
+
+// Returns the sorted names of the realms chains were assigned to.
+func (r *Rbac) Realms() []string {
+	return sortedKeys(r.realms)
+}
+
+// Returns the realm of the chain of the flattened role, empty if the role is unknown or its chain has no realm.
+func (r *Rbac) RoleRealm(role string) string {
+	return r.chainToRealm[r.roleToChain[role]]
+}
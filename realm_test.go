@@ -0,0 +1,54 @@
+package rbac_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_InRealm(t *testing.T) {
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("invoices").Realm("billing").Add("Viewer", []string{"view"}).Add("Editor", []string{"edit"}),
+		rbac.Chain("docs").Realm("wiki").Add("Viewer", []string{"view"}),
+		rbac.Chain("auth").Add("Authenticated", []string{"login"}),
+	}, rbac.WithDefaultRoles("auth.Authenticated"), rbac.WithRoleAliases(map[string]string{"docs.Reader": "docs.Viewer"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(r.Realms(), []string{"billing", "wiki"}) {
+		t.Fatal("should list the realms, got", r.Realms())
+	}
+	if r.RoleRealm("invoices.Editor") != "billing" || r.RoleRealm("auth.Authenticated") != "" {
+		t.Fatal("should map a flattened role to the realm of its chain")
+	}
+	billing := r.InRealm("billing")
+	if billing == nil || billing.InRealm("billing") != billing {
+		t.Fatal("should return the view of the realm")
+	}
+	if !slices.Equal(billing.AllRoles(), []string{"invoices.Editor", "invoices.Viewer"}) {
+		t.Fatal("should only have the roles of the realm, got", billing.AllRoles())
+	}
+	if billing.Authorizer("docs.Viewer").HasPermission("view") {
+		t.Fatal("should not consider roles of other realms")
+	}
+	if !billing.Authorizer("invoices.Viewer").HasPermission("view") || billing.Authorizer("invoices.Viewer").HasPermission("login") {
+		t.Fatal("should only consider the permissions of the realm")
+	}
+	if !r.Authorizer("docs.Viewer").HasPermission("view") || !r.InRealm("wiki").Authorizer("docs.Reader").HasPermission("view") {
+		t.Fatal("should keep the full model and aliases of the realm")
+	}
+	if r.InRealm("unknown") != nil {
+		t.Fatal("should return nil for an unknown realm")
+	}
+}
+
+func Test_InRealmExtendsOtherRealm(t *testing.T) {
+	_, err := rbac.NewRbac(
+		rbac.Chain("base").Realm("a").Add("Member", []string{"read"}),
+		rbac.Chain("derived").Realm("b").Extends("base").Add("Member", []string{"write"}),
+	)
+	if err == nil || err.Error() != "realm b: chain derived extends unknown chain base" {
+		t.Fatal("should reject extending a chain of another realm, got", err)
+	}
+}
@@ -0,0 +1,68 @@
+package rbac
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returns a human-readable report of what a subject with the roles can do, e.g. to paste into a support ticket:
+//
+//	Roles:
+//	  auth.Authenticated
+//	  billing.Viewer
+//	Chains:
+//	  auth
+//	  billing
+//	Permissions:
+//	  (no prefix)
+//	    create
+//	    list
+//	  billing
+//	    billing.read: Lists the invoices
+//
+// Permissions are grouped by the prefix before their first dot, followed by their description if they have one,
+// and every list is sorted.
+// Unknown roles are marked as such and give no permissions.
+func (r *Rbac) Report(roles []string) string {
+	a := r.Authorizer(roles...)
+	chainSet := map[string]bool{}
+	b := &strings.Builder{}
+	fmt.Fprintln(b, "Roles:")
+	for _, role := range a.Roles() {
+		chain, ok := a.rbac.roleToChain[role]
+		if !ok {
+			fmt.Fprintf(b, "  %s (unknown)\n", role)
+			continue
+		}
+		chainSet[chain] = true
+		fmt.Fprintf(b, "  %s\n", role)
+	}
+	fmt.Fprintln(b, "Chains:")
+	for _, chain := range sortedKeys(chainSet) {
+		fmt.Fprintf(b, "  %s\n", chain)
+	}
+	fmt.Fprintln(b, "Permissions:")
+	prefixToPermissions := map[string][]string{}
+	for _, permission := range a.Permissions() {
+		prefix, _, found := strings.Cut(permission, ".")
+		if !found {
+			prefix = ""
+		}
+		prefixToPermissions[prefix] = append(prefixToPermissions[prefix], permission)
+	}
+	for _, prefix := range sortedKeys(prefixToPermissions) {
+		if prefix == "" {
+			fmt.Fprintln(b, "  (no prefix)")
+		} else {
+			fmt.Fprintf(b, "  %s\n", prefix)
+		}
+		for _, permission := range prefixToPermissions[prefix] {
+			if description, ok := r.permissionToDescription[permission]; ok {
+				fmt.Fprintf(b, "    %s: %s\n", permission, description)
+				continue
+			}
+			fmt.Fprintf(b, "    %s\n", permission)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_Report(t *testing.T) {
+	r, err := rbac.NewRbac(
+		rbac.Chain("auth").Add("Unauthenticated", []string{"list"}).Add("Authenticated", []string{"create"}),
+		rbac.Chain("billing").Add("Viewer", []string{"billing.read", "billing.export"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Roles:
+  auth.Authenticated
+  billing.Owner (unknown)
+  billing.Viewer
+Chains:
+  auth
+  billing
+Permissions:
+  (no prefix)
+    create
+    list
+  billing
+    billing.export
+    billing.read
+`
+	if got := r.Report([]string{"billing.Viewer", "auth.Authenticated", "billing.Owner"}); got != want {
+		t.Fatalf("unexpected report:\n%s", got)
+	}
+}
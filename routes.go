@@ -0,0 +1,89 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The permission required by requests with a method and path prefix.
+type route struct {
+	// The id of the role in its chain that gives the permission.
+	roleId string
+	// The upper cased http method, empty for any method.
+	method string
+	// The path prefix of the requests.
+	pathPrefix string
+	// The permission the requests require.
+	permission string
+}
+
+// Declares that requests with the method and path prefix require the permission, which the role with the given
+// id must give, so a middleware can resolve requests with Rbac.PermissionForRequest instead of a hand-maintained
+// map. An empty method or "*" matches any method. It does not change the permissions of any role.
+// NewRbac returns an error if the role does not exist or does not give the permission.
+func (c *RoleChain) AddRoute(id, method, pathPrefix, permission string) *RoleChain {
+	if method == "*" {
+		method = ""
+	}
+	c.routes = append(c.routes, &route{
+		roleId:     id,
+		method:     strings.ToUpper(method),
+		pathPrefix: pathPrefix,
+		permission: permission,
+	})
+	return c
+}
+
+// Registers the routes of the chains, which must refer to roles of the chain that give their permissions. Returns
+// the errors of all invalid routes joined.
+func (r *Rbac) addRoutes(roleChains []*RoleChain) error {
+	errs := []error{}
+	for _, chain := range roleChains {
+		for _, rt := range chain.routes {
+			roleName := r.flatten(chain.name, rt.roleId)
+			if !r.chainToRoleIdSet[chain.name][rt.roleId] {
+				errs = append(errs, fmt.Errorf("route %s %s for unknown role %s", rt.method, rt.pathPrefix, roleName))
+				continue
+			}
+			if !r.roleGives(roleName, r.transformPermission(rt.permission)) {
+				errs = append(errs, fmt.Errorf("route %s %s permission %q is not given by role %s", rt.method, rt.pathPrefix, rt.permission, roleName))
+				continue
+			}
+			r.routes = append(r.routes, rt)
+		}
+	}
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		if len(r.routes[i].pathPrefix) != len(r.routes[j].pathPrefix) {
+			return len(r.routes[i].pathPrefix) > len(r.routes[j].pathPrefix)
+		}
+		return r.routes[i].method != "" && r.routes[j].method == ""
+	})
+	return errors.Join(errs...)
+}
+
+// Returns the permission required by a request and whether any route matches it. The route with the longest
+// matching path prefix wins, then a route with the exact method beats one for any method, then the route
+// registered first. A prefix matches the path itself and paths beneath it, e.g. "/accounts" matches
+// "/accounts/1" but not "/accountsx".
+func (r *Rbac) PermissionForRequest(method, path string) (string, bool) {
+	method = strings.ToUpper(method)
+	for _, rt := range r.routes {
+		if rt.method != "" && rt.method != method {
+			continue
+		}
+		if matchesPathPrefix(path, rt.pathPrefix) {
+			return rt.permission, true
+		}
+	}
+	return "", false
+}
+
+// Returns whether the path is the prefix or beneath it.
+func matchesPathPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
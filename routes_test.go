@@ -0,0 +1,51 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_PermissionForRequest(t *testing.T) {
+	r, err := rbac.NewRbac(rbac.Chain("use.Account").
+		Add("Member", []string{"get", "list"}).
+		Add("Admin", []string{"update", "billing"}).
+		AddRoute("Member", "*", "/accounts", "list").
+		AddRoute("Member", "get", "/accounts/", "get").
+		AddRoute("Admin", "PUT", "/accounts/", "update").
+		AddRoute("Admin", "", "/accounts/billing", "billing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[[2]string]string{
+		{"GET", "/accounts"}:           "list",
+		{"POST", "/accounts"}:          "list",
+		{"GET", "/accounts/1"}:         "get",
+		{"PUT", "/accounts/1"}:         "update",
+		{"DELETE", "/accounts/1"}:      "list",
+		{"GET", "/accounts/billing/2"}: "billing",
+		{"GET", "/accountsx"}:          "",
+		{"GET", "/users"}:              "",
+	}
+	for request, want := range tests {
+		permission, ok := r.PermissionForRequest(request[0], request[1])
+		if permission != want || ok != (want != "") {
+			t.Fatalf("%v: expected %q, got %q", request, want, permission)
+		}
+	}
+
+	_, err = rbac.NewRbac(rbac.Chain("use.Account").Add("Member", []string{"get"}).AddRoute("Member", "PUT", "/accounts", "update"))
+	if err == nil || err.Error() != `route PUT /accounts permission "update" is not given by role use.Account.Member` {
+		t.Fatalf("should reject permissions the role does not give, got %v", err)
+	}
+	_, err = rbac.NewRbac(rbac.Chain("use.Account").Add("Member", []string{"get"}).AddRoute("Admin", "GET", "/accounts", "get"))
+	if err == nil || err.Error() != "route GET /accounts for unknown role use.Account.Admin" {
+		t.Fatalf("should reject unknown roles, got %v", err)
+	}
+	_, err = rbac.NewRbac(rbac.Chain("use.Account").Add("Member", []string{"get"}).
+		AddRoute("Admin", "GET", "/accounts", "get").AddRoute("Member", "PUT", "/accounts", "update"))
+	want := "route GET /accounts for unknown role use.Account.Admin\n" + `route PUT /accounts permission "update" is not given by role use.Account.Member`
+	if err == nil || err.Error() != want {
+		t.Fatalf("should report every invalid route, got %v", err)
+	}
+}
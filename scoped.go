@@ -0,0 +1,190 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Adds roles that only count for the resource, e.g. admin of one account but member of another. A scoped deny role
+// denies its permissions on the resource, even if a global role gives them. Role aliases are resolved and, with
+// WithStrictRoleAdditions, unknown roles are rejected like in Add.
+func (a *Authorizer) AddScoped(resource string, roles ...string) {
+	value, _ := a.scopes.LoadOrStore(resource, &sync.Map{})
+	scope := value.(*sync.Map)
+	added := false
+	for _, role := range roles {
+		resolved := a.rbac.resolveRole(role)
+		if _, ok := a.rbac.roleToPermissionSet[resolved]; !ok && a.rbac.config.strictRoleAdditions {
+			a.errors.Store(fmt.Sprintf("role %s not allowed", role), true)
+			continue
+		}
+		scope.Store(resolved, true)
+		added = true
+	}
+	if added {
+		a.roleGeneration.Add(1)
+	}
+}
+
+// Returns whether the global roles or the roles scoped to the resource give the permission after waiting for
+// async role additions.
+func (a *Authorizer) HasPermissionOn(resource, permission string) bool {
+	a.wait()
+	return a.hasPermissionOn(resource, a.rbac.transformPermission(permission))
+}
+
+// Returns whether the authorizer has the permission for at least one of the resources after waiting for async
+// role additions, e.g. as a quick pre-check for a batch. A global role that gives the permission satisfies any
+// resource, and without resources only global roles count.
+func (a *Authorizer) HasPermissionForAny(permission string, resources ...string) bool {
+	a.wait()
+	permission = a.rbac.transformPermission(permission)
+	allowed := []string{}
+	for _, resource := range resources {
+		if !a.scopeDenies(resource, permission) {
+			allowed = append(allowed, resource)
+		}
+	}
+	if len(resources) > 0 && len(allowed) == 0 {
+		return false
+	}
+	return a.decide(context.Background(), permission, func(permission string) bool {
+		for _, resource := range allowed {
+			if a.scopeGives(resource, permission) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Returns whether the global roles or the roles scoped to any resource give the permission after waiting for
+// async role additions, e.g. to show a menu entry if the caller may use it somewhere. HasPermission keeps only
+// counting global roles so a role scoped to one resource never passes checks meant for all of them.
+func (a *Authorizer) HasPermissionAnywhere(permission string) bool {
+	a.wait()
+	return a.decide(context.Background(), a.rbac.transformPermission(permission), func(permission string) bool {
+		gives := false
+		a.scopes.Range(func(resource, _ interface{}) bool {
+			gives = a.scopeGives(resource.(string), permission)
+			return !gives
+		})
+		return gives
+	})
+}
+
+// Returns the sorted permissions the global roles and the roles scoped to the resource give after waiting for
+// async role additions, e.g. to show the capabilities on a document. Like HasPermissionOn it leaves out denied
+// permissions and roles whose gate is disabled.
+func (a *Authorizer) PermissionsForResource(resource string) []string {
+	a.wait()
+	set := map[string]bool{}
+	collect := func(key, _ interface{}) bool {
+		role := key.(string)
+		if !a.rbac.roleEnabled(role) {
+			return true
+		}
+		for permission := range a.rbac.rolePermissionSet(role) {
+			if !a.isDenied(permission) && !a.scopeDenies(resource, permission) {
+				set[permission] = true
+			}
+		}
+		return true
+	}
+	a.roles.Range(collect)
+	if value, ok := a.scopes.Load(resource); ok {
+		value.(*sync.Map).Range(collect)
+	}
+	return sortedKeys(set)
+}
+
+// Returns whether the global roles or the roles scoped to the resource give the transformed permission without
+// waiting.
+func (a *Authorizer) hasPermissionOn(resource, permission string) bool {
+	if a.scopeDenies(resource, permission) {
+		return false
+	}
+	return a.decide(context.Background(), permission, func(permission string) bool {
+		return a.scopeGives(resource, permission)
+	})
+}
+
+// Returns whether a role scoped to the resource gives the transformed permission.
+func (a *Authorizer) scopeGives(resource, permission string) bool {
+	if permission == "" {
+		return false
+	}
+	if a.isDenied(permission) || a.scopeDenies(resource, permission) {
+		return false
+	}
+	value, ok := a.scopes.Load(resource)
+	if !ok {
+		return false
+	}
+	gives := false
+	value.(*sync.Map).Range(func(key, _ interface{}) bool {
+		role := key.(string)
+		gives = a.rbac.roleEnabled(role) && a.rbac.roleGrants(role, permission)
+		return !gives
+	})
+	return gives
+}
+
+// Returns whether a deny role scoped to the resource denies the transformed permission.
+func (a *Authorizer) scopeDenies(resource, permission string) bool {
+	roles := a.rbac.permissionToDenyRoleSet[permission]
+	if len(roles) == 0 {
+		return false
+	}
+	value, ok := a.scopes.Load(resource)
+	if !ok {
+		return false
+	}
+	scope := value.(*sync.Map)
+	for role := range roles {
+		if _, ok := scope.Load(role); ok && a.rbac.roleEnabled(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the enabled roles scoped to the resource that deny the transformed permission, sorted.
+func (a *Authorizer) scopeDenyingRoles(resource, permission string) []string {
+	roles := []string{}
+	value, ok := a.scopes.Load(resource)
+	if !ok {
+		return roles
+	}
+	scope := value.(*sync.Map)
+	for role := range a.rbac.permissionToDenyRoleSet[permission] {
+		if _, ok := scope.Load(role); ok && a.rbac.roleEnabled(role) {
+			roles = append(roles, role)
+		}
+	}
+	slices.Sort(roles)
+	return roles
+}
+
+// Returns whether the role gives the permission, also using wildcard permissions and the matcher if the rbac has one.
+func (r *Rbac) roleGrants(role, permission string) bool {
+	if r.roleGives(role, permission) {
+		return true
+	}
+	for _, granting := range r.wildcardGrantingRoles(permission) {
+		if granting == role {
+			return true
+		}
+	}
+	if r.config.matcher == nil {
+		return false
+	}
+	for granted := range r.rolePermissionSet(role) {
+		if r.match(granted, permission) {
+			return true
+		}
+	}
+	return false
+}
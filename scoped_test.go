@@ -0,0 +1,65 @@
+package rbac_test
+
+import (
+	"slices"
+	"testing"
+)
+
+func Test_AddScoped(t *testing.T) {
+	az := Rbac.Authorizer("auth.Authenticated")
+	az.AddScoped("account-a", "use.Account.Admin")
+	az.AddScoped("account-b", "use.Account.Member")
+	if !az.HasPermissionOn("account-a", "delete") || az.HasPermissionOn("account-b", "delete") {
+		t.Fatal("scoped roles should only count for their resource")
+	}
+	if !az.HasPermissionOn("account-b", "get") || az.HasPermissionOn("account-c", "get") {
+		t.Fatal("should check the roles of the resource")
+	}
+	if !az.HasPermissionOn("account-c", "create") {
+		t.Fatal("global roles should count for every resource")
+	}
+}
+
+func Test_HasPermissionForAny(t *testing.T) {
+	az := Rbac.Authorizer("auth.Authenticated")
+	az.AddScoped("account-a", "use.Account.Member")
+	az.AddScoped("account-b", "use.Account.Admin")
+	if !az.HasPermissionForAny("delete", "account-a", "account-b") || az.HasPermissionForAny("delete", "account-a", "account-c") {
+		t.Fatal("should have the permission if any resource gives it")
+	}
+	if !az.HasPermissionForAny("create", "account-c") || !az.HasPermissionForAny("create") {
+		t.Fatal("a global grant should satisfy any resource")
+	}
+	if az.HasPermissionForAny("get") {
+		t.Fatal("without resources only global roles should count")
+	}
+}
+
+func Test_HasPermissionAnywhere(t *testing.T) {
+	az := Rbac.Authorizer("auth.Authenticated")
+	az.AddScoped("account-a", "use.Account.Member")
+	az.AddScoped("account-b", "use.Account.Admin")
+	if !az.HasPermissionAnywhere("delete") || !az.HasPermissionAnywhere("create") || az.HasPermissionAnywhere("unknown") {
+		t.Fatal("should count the global roles and the roles of every resource")
+	}
+	if az.HasPermission("delete") {
+		t.Fatal("HasPermission should only count global roles")
+	}
+}
+
+func Test_PermissionsForResource(t *testing.T) {
+	az := Rbac.Authorizer("auth.Authenticated")
+	az.AddScoped("account-a", "use.Account.Admin")
+	if got := az.PermissionsForResource("account-a"); !slices.Equal(got, []string{"create", "delete", "get", "list", "update"}) {
+		t.Fatal("should union the global and scoped permissions, got", got)
+	}
+	if got := az.PermissionsForResource("account-b"); !slices.Equal(got, []string{"create", "list"}) {
+		t.Fatal("should only have the global permissions on other resources, got", got)
+	}
+	az.AddDeniesAsync(func() ([]string, error) {
+		return []string{"list", "delete"}, nil
+	})
+	if got := az.PermissionsForResource("account-a"); !slices.Equal(got, []string{"create", "get", "update"}) {
+		t.Fatal("denies should override global and scoped grants, got", got)
+	}
+}
@@ -0,0 +1,55 @@
+package rbac
+
+import (
+	"errors"
+	"strings"
+)
+
+// The default separator between the chain name and the role id of a flattened role name.
+const defaultRoleSeparator = "."
+
+// Returns an option that joins chain names and role ids of flattened role names with sep instead of ".", e.g.
+// "use.Account/Admin" with "/", so roles of dotted chain names can be split reliably with SplitRole.
+// The separator must not be empty.
+func WithRoleSeparator(sep string) Option {
+	return func(o *options) {
+		o.roleSeparator = &sep
+	}
+}
+
+// Returns a new role-based access controller made up of the role chains whose flattened role names join the
+// chain name and the role id with sep, see WithRoleSeparator.
+func NewRbacWithSep(sep string, roleChains ...*RoleChain) (*Rbac, error) {
+	return NewRbacWithOptions(roleChains, WithRoleSeparator(sep))
+}
+
+// Returns an error if the configured role separator is empty.
+func (o *options) validateRoleSeparator() error {
+	if o.roleSeparator != nil && *o.roleSeparator == "" {
+		return errors.New("empty role separator")
+	}
+	return nil
+}
+
+// Returns the separator between the chain name and the role id of flattened role names.
+func (r *Rbac) roleSeparator() string {
+	if r.config == nil || r.config.roleSeparator == nil {
+		return defaultRoleSeparator
+	}
+	return *r.config.roleSeparator
+}
+
+// Returns the flattened name of the role of the chain.
+func (r *Rbac) flatten(chain, roleId string) string {
+	return chain + r.roleSeparator() + roleId
+}
+
+// Returns the chain name and the role id of the flattened role and whether it exists. The chain is looked up
+// among the known chains, so it is split correctly even if the chain name contains the separator.
+func (r *Rbac) SplitRole(role string) (chain, roleId string, ok bool) {
+	chain, ok = r.roleToChain[role]
+	if !ok {
+		return "", "", false
+	}
+	return chain, strings.TrimPrefix(role, chain+r.roleSeparator()), true
+}
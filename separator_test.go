@@ -0,0 +1,56 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_NewRbacWithSep(t *testing.T) {
+	r, err := rbac.NewRbacWithSep("/",
+		rbac.Chain("use.Account").Add("Member", []string{"get"}).Add("Admin", []string{"delete"}),
+		rbac.Chain("use").Add("Account", []string{"list"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Authorizer("use.Account/Admin").HasPermission("get") || r.Authorizer("use.Account.Admin").HasPermission("get") {
+		t.Fatal("should join chain names and role ids with the separator")
+	}
+	if chain, roleId, ok := r.SplitRole("use.Account/Admin"); !ok || chain != "use.Account" || roleId != "Admin" {
+		t.Fatal("should split at the known chain, got", chain, roleId)
+	}
+	if _, _, ok := r.SplitRole("use.Account/Owner"); ok {
+		t.Fatal("should not split unknown roles")
+	}
+	if err := r.ValidateRole("use.Account/Owner"); err == nil || err.Error() != "chain use.Account has no role Owner" {
+		t.Fatal("should validate with the separator, got", err)
+	}
+	if chain, roleId, ok := Rbac.SplitRole("use.Account.Admin"); !ok || chain != "use.Account" || roleId != "Admin" {
+		t.Fatal("should split dotted chains with the default separator, got", chain, roleId)
+	}
+
+	if _, err := rbac.NewRbacWithSep("", rbac.Chain("a").Add("R", nil)); err == nil || err.Error() != "empty role separator" {
+		t.Fatal("should reject an empty separator, got", err)
+	}
+	_, err = rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("a.b").Add("c", []string{"x"}),
+		rbac.Chain("a").Add("b.c", []string{"y"}),
+	}, rbac.WithDuplicatePolicy(rbac.DuplicateUnion))
+	if err == nil || err.Error() != "role a.b.c is ambiguous between chains a.b and a" {
+		t.Fatal("should reject role names of different chains that collide, got", err)
+	}
+}
+
+func Test_NewRbacWithSep_EachChain(t *testing.T) {
+	r, err := rbac.NewRbacWithSep("::", rbac.Chain("auth").Add("Member", []string{"get"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.EachChain(func(chain string, roles []*rbac.Role) bool {
+		if len(roles) != 1 || roles[0].Id != "Member" {
+			t.Fatal("should strip a separator of several characters from the role ids, got", roles[0].Id)
+		}
+		return true
+	})
+}
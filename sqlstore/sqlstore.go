@@ -0,0 +1,98 @@
+// Package sqlstore loads role chains from a SQL database, e.g. to rebuild an rbac on deploy or reload.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+// The queries of LoadFromDB. Each query takes no arguments and must return its rows in registration order,
+// which usually requires an order column, e.g. "SELECT chain, id FROM roles ORDER BY chain, position".
+type Queries struct {
+	// Returns the name and description of every chain.
+	Chains string
+	// Returns the chain name and id of every role. Within a chain every role extends the previous roles.
+	Roles string
+	// Returns the chain name, role id and permission of every permission a role adds to the previous roles.
+	Permissions string
+}
+
+// Returns a new role-based access controller made up of the chains in the database, validated like in NewRbac.
+func LoadFromDB(ctx context.Context, db *sql.DB, queries Queries) (*rbac.Rbac, error) {
+	config := rbac.Config{}
+	chainToIndex := map[string]int{}
+	err := query(ctx, db, "chains", queries.Chains, func(rows *sql.Rows) error {
+		chain := rbac.ChainConfig{}
+		var description sql.NullString
+		if err := rows.Scan(&chain.Name, &description); err != nil {
+			return err
+		}
+		chain.Description = description.String
+		chainToIndex[chain.Name] = len(config.Chains)
+		config.Chains = append(config.Chains, chain)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	roleToIndex := map[string]int{}
+	err = query(ctx, db, "roles", queries.Roles, func(rows *sql.Rows) error {
+		var chainName, id string
+		if err := rows.Scan(&chainName, &id); err != nil {
+			return err
+		}
+		i, ok := chainToIndex[chainName]
+		if !ok {
+			return fmt.Errorf("role %s of unknown chain %s", id, chainName)
+		}
+		chain := &config.Chains[i]
+		role := rbac.RoleConfig{Id: id, Permissions: []string{}}
+		if len(chain.Roles) > 0 {
+			role.Extends = chain.Roles[len(chain.Roles)-1].Id
+		}
+		roleToIndex[chainName+"."+id] = len(chain.Roles)
+		chain.Roles = append(chain.Roles, role)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = query(ctx, db, "permissions", queries.Permissions, func(rows *sql.Rows) error {
+		var chainName, id, permission string
+		if err := rows.Scan(&chainName, &id, &permission); err != nil {
+			return err
+		}
+		j, ok := roleToIndex[chainName+"."+id]
+		if !ok {
+			return fmt.Errorf("permission %s of unknown role %s.%s", permission, chainName, id)
+		}
+		role := &config.Chains[chainToIndex[chainName]].Roles[j]
+		role.Permissions = append(role.Permissions, permission)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return config.Build()
+}
+
+// Runs the query and calls scan for every row, wrapping errors with the name of what is queried.
+func query(ctx context.Context, db *sql.DB, name, query string, scan func(rows *sql.Rows) error) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("querying %s: %w", name, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return fmt.Errorf("scanning %s: %w", name, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+	return nil
+}
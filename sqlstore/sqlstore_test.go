@@ -0,0 +1,162 @@
+package sqlstore_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/acudac-com/rbac-go/sqlstore"
+)
+
+// A driver whose databases answer every query with the rows registered for it.
+type fakeDriver struct{}
+
+// The rows of every query of the fake databases by data source name.
+var fakeData = map[string]map[string][][]driver.Value{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{queries: fakeData[name]}, nil
+}
+
+// A connection of a fake database.
+type fakeConn struct {
+	queries map[string][][]driver.Value
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{conn: c, query: query}, nil
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions not supported")
+}
+
+// A statement of a fake database.
+type fakeStmt struct {
+	conn  fakeConn
+	query string
+}
+
+func (fakeStmt) Close() error {
+	return nil
+}
+
+func (fakeStmt) NumInput() int {
+	return 0
+}
+
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("exec not supported")
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows, ok := s.conn.queries[s.query]
+	if !ok {
+		return nil, fmt.Errorf("unknown query %q", s.query)
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+// The rows of a fake query.
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return []string{}
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("fake", fakeDriver{})
+}
+
+// The queries of the tests.
+var queries = sqlstore.Queries{
+	Chains:      "SELECT name, description FROM chains ORDER BY position",
+	Roles:       "SELECT chain, id FROM roles ORDER BY chain, position",
+	Permissions: "SELECT chain, role_id, permission FROM permissions",
+}
+
+// Returns a fake database answering the queries with the rows.
+func fakeDB(t *testing.T, name string, chains, roles, permissions [][]driver.Value) *sql.DB {
+	fakeData[name] = map[string][][]driver.Value{
+		queries.Chains:      chains,
+		queries.Roles:       roles,
+		queries.Permissions: permissions,
+	}
+	db, err := sql.Open("fake", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func Test_LoadFromDB(t *testing.T) {
+	db := fakeDB(t, "valid",
+		[][]driver.Value{{"auth", "Whether the caller is signed in"}, {"use.Account", nil}},
+		[][]driver.Value{{"auth", "Unauthenticated"}, {"auth", "Authenticated"}, {"use.Account", "Member"}, {"use.Account", "Admin"}},
+		[][]driver.Value{{"auth", "Unauthenticated", "list"}, {"auth", "Authenticated", "create"}, {"use.Account", "Member", "get"}, {"use.Account", "Admin", "update"}, {"use.Account", "Admin", "delete"}},
+	)
+	r, err := sqlstore.LoadFromDB(context.Background(), db, queries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.ChainNames(), []string{"auth", "use.Account"}) {
+		t.Fatal("should keep the chain order, got", r.ChainNames())
+	}
+	if !reflect.DeepEqual(r.EffectivePermissionsForRole("use.Account.Admin"), []string{"delete", "get", "update"}) {
+		t.Fatal("should extend the previous roles, got", r.EffectivePermissionsForRole("use.Account.Admin"))
+	}
+	if info, _ := r.ChainInfo("auth"); info.Description != "Whether the caller is signed in" {
+		t.Fatal("should load descriptions")
+	}
+}
+
+func Test_LoadFromDBErrors(t *testing.T) {
+	tests := map[string]struct {
+		chains, roles, permissions [][]driver.Value
+		want                       string
+	}{
+		"unknown chain":   {[][]driver.Value{{"auth", nil}}, [][]driver.Value{{"ops", "Admin"}}, nil, "scanning roles: role Admin of unknown chain ops"},
+		"unknown role":    {[][]driver.Value{{"auth", nil}}, [][]driver.Value{{"auth", "Member"}}, [][]driver.Value{{"auth", "Admin", "get"}}, "scanning permissions: permission get of unknown role auth.Admin"},
+		"invalid":         {[][]driver.Value{{"auth", nil}}, [][]driver.Value{{"auth", "Member"}}, [][]driver.Value{{"auth", "Member", ""}}, "role auth.Member has an empty permission"},
+		"scan":            {[][]driver.Value{{"auth"}}, nil, nil, "scanning chains: sql: expected 1 destination arguments in Scan, not 2"},
+		"no chains found": {nil, nil, nil, "no role chains provided"},
+	}
+	for name, test := range tests {
+		db := fakeDB(t, name, test.chains, test.roles, test.permissions)
+		if _, err := sqlstore.LoadFromDB(context.Background(), db, queries); err == nil || err.Error() != test.want {
+			t.Fatalf("%s: should return %q, got %v", name, test.want, err)
+		}
+	}
+	db := fakeDB(t, "failing", nil, nil, nil)
+	_, err := sqlstore.LoadFromDB(context.Background(), db, sqlstore.Queries{Chains: "SELECT"})
+	if err == nil || err.Error() != `querying chains: unknown query "SELECT"` {
+		t.Fatal("should wrap query errors, got", err)
+	}
+}
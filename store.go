@@ -0,0 +1,77 @@
+package rbac
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Persists which roles are assigned to which subjects.
+type AssignmentStore interface {
+	// Returns the roles assigned to the subject.
+	Roles(subject string) ([]string, error)
+	// Assigns the role to the subject.
+	Grant(subject, role string) error
+	// Removes the role from the subject.
+	Revoke(subject, role string) error
+}
+
+// An in-memory assignment store that is safe for concurrent use.
+type MemoryStore struct {
+	// The rbac grants are validated against.
+	rbac *Rbac
+	// Guards subjectToRoleSet.
+	mu sync.RWMutex
+	// The roles assigned to each subject.
+	subjectToRoleSet map[string]map[string]bool
+}
+
+var _ AssignmentStore = (*MemoryStore)(nil)
+
+// Returns an empty in-memory assignment store that only accepts grants of roles of the rbac.
+func NewMemoryStore(r *Rbac) *MemoryStore {
+	return &MemoryStore{
+		rbac:             r,
+		subjectToRoleSet: map[string]map[string]bool{},
+	}
+}
+
+// Returns the sorted roles assigned to the subject, empty if none are.
+func (s *MemoryStore) Roles(subject string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedKeys(s.subjectToRoleSet[subject]), nil
+}
+
+// Assigns the role to the subject or returns an error if the role does not exist.
+func (s *MemoryStore) Grant(subject, role string) error {
+	if err := s.rbac.ValidateRole(role); err != nil {
+		return fmt.Errorf("granting %s to %s: %w", role, subject, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subjectToRoleSet[subject]; !ok {
+		s.subjectToRoleSet[subject] = map[string]bool{}
+	}
+	s.subjectToRoleSet[subject][role] = true
+	return nil
+}
+
+// Removes the role from the subject. Revoking a role the subject does not have is not an error.
+func (s *MemoryStore) Revoke(subject, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subjectToRoleSet[subject], role)
+	if len(s.subjectToRoleSet[subject]) == 0 {
+		delete(s.subjectToRoleSet, subject)
+	}
+	return nil
+}
+
+// Returns an authorizer for the subject that asynchronously adds the roles assigned to it in the store.
+func (r *Rbac) AuthorizerForSubject(store AssignmentStore, subject string) *Authorizer {
+	a := r.Authorizer().WithSubject(subject)
+	a.AddAsync(func() ([]string, error) {
+		return store.Roles(subject)
+	})
+	return a
+}
@@ -0,0 +1,45 @@
+package rbac_test
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_MemoryStore(t *testing.T) {
+	store := rbac.NewMemoryStore(Rbac)
+	if err := store.Grant("alice", "use.Account.Owner"); err == nil {
+		t.Fatal("should reject unknown roles")
+	}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store.Grant(fmt.Sprintf("user-%d", i), "auth.Authenticated")
+		}()
+	}
+	wg.Wait()
+	if err := store.Grant("alice", "use.Account.Admin"); err != nil {
+		t.Fatal(err)
+	}
+	store.Grant("alice", "auth.Authenticated")
+	if roles, _ := store.Roles("alice"); !reflect.DeepEqual(roles, []string{"auth.Authenticated", "use.Account.Admin"}) {
+		t.Fatalf("should return the granted roles, got %v", roles)
+	}
+
+	az := Rbac.AuthorizerForSubject(store, "alice")
+	if az.Subject() != "alice" || !az.HasPermission("delete") {
+		t.Fatal("should add the roles of the subject")
+	}
+	store.Revoke("alice", "use.Account.Admin")
+	if Rbac.AuthorizerForSubject(store, "alice").HasPermission("delete") {
+		t.Fatal("should not have revoked roles")
+	}
+	if !Rbac.AuthorizerForSubject(store, "user-3").HasPermission("create") {
+		t.Fatal("should have concurrently granted roles")
+	}
+}
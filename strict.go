@@ -0,0 +1,43 @@
+package rbac
+
+import "fmt"
+
+// A role chain builder that validates every role when it is added instead of in NewRbac.
+type StrictRoleChain struct {
+	// The chain the roles are added to.
+	chain *RoleChain
+	// The ids of the added roles.
+	idSet map[string]bool
+}
+
+// Returns a new strict chain to add roles which extend each other's permissions, rejecting mistakes at the
+// point they are made, e.g. when building chains from untrusted input. NewRbac still validates across chains.
+func StrictChain(name string) *StrictRoleChain {
+	return &StrictRoleChain{
+		chain: Chain(name),
+		idSet: map[string]bool{},
+	}
+}
+
+// Adds a role like RoleChain.Add and returns the underlying chain, or an error if the id is empty or already
+// added or a permission is empty, in which case nothing is added.
+func (c *StrictRoleChain) Add(id string, permissions []string) (*RoleChain, error) {
+	if id == "" {
+		return nil, fmt.Errorf("chain %s: empty role id", c.chain.name)
+	}
+	if c.idSet[id] {
+		return nil, fmt.Errorf("chain %s: duplicate role id %s", c.chain.name, id)
+	}
+	for _, permission := range permissions {
+		if permission == "" {
+			return nil, fmt.Errorf("role %s.%s has an empty permission", c.chain.name, id)
+		}
+	}
+	c.idSet[id] = true
+	return c.chain.Add(id, permissions), nil
+}
+
+// Returns the underlying chain to pass to NewRbac.
+func (c *StrictRoleChain) Chain() *RoleChain {
+	return c.chain
+}
@@ -0,0 +1,37 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_StrictChain(t *testing.T) {
+	chain := rbac.StrictChain("use.Account")
+	if _, err := chain.Add("Member", []string{"get"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.Add("Admin", []string{"update"}); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		id          string
+		permissions []string
+	}{
+		"chain use.Account: empty role id":               {"", []string{"delete"}},
+		"chain use.Account: duplicate role id Member":    {"Member", []string{"delete"}},
+		"role use.Account.Owner has an empty permission": {"Owner", []string{"delete", ""}},
+	}
+	for want, test := range tests {
+		if _, err := chain.Add(test.id, test.permissions); err == nil || err.Error() != want {
+			t.Fatalf("should fail with %q, got %v", want, err)
+		}
+	}
+	r, err := rbac.NewRbac(chain.Chain())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Authorizer("use.Account.Admin").HasPermission("get") || r.IsValidRole("use.Account.Owner") {
+		t.Fatal("should only contain the valid roles")
+	}
+}
@@ -0,0 +1,94 @@
+package rbac
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The maximum edit distance of a suggested permission.
+const maxSuggestionDistance = 2
+
+// The maximum number of known permissions compared when looking for a suggestion.
+const maxSuggestionCandidates = 10000
+
+// Returns whether one of the roles give the specified permission, or an error if the permission is not
+// given by any role in the rbac, which usually means it is misspelled. The error suggests the closest
+// known permission if there is one within an edit distance of 2. An empty permission is always an error.
+func (a *Authorizer) CheckPermission(permission string) (bool, error) {
+	a.wait()
+	if permission == "" {
+		return false, fmt.Errorf("empty permission")
+	}
+	transformed := a.rbac.transformPermission(permission)
+	if !a.rbac.isDefined(transformed) {
+		if suggestion := a.rbac.suggestPermission(transformed); suggestion != "" {
+			return false, fmt.Errorf("unknown permission %q, did you mean %q?", permission, suggestion)
+		}
+		return false, fmt.Errorf("unknown permission %q", permission)
+	}
+	return a.gives(transformed), nil
+}
+
+// Returns a concise explanation of why HasPermission is false after waiting for async role additions, e.g. for a
+// 403 response: "explicitly denied by role ..." naming the held deny roles, "explicitly denied" for a deny of the
+// authorizer itself, "permission undefined" or "no role grants it, needs one of: ..." listing the roles of
+// RolesWithPermission. Returns an empty string if the permission is granted.
+func (a *Authorizer) DenyReason(permission string) string {
+	a.wait()
+	permission = a.rbac.transformPermission(permission)
+	switch {
+	case a.gives(permission):
+		return ""
+	case a.isDenied(permission):
+		return denyReason(a.denyingRoles(permission))
+	case !a.rbac.isDefined(permission):
+		return "permission undefined"
+	}
+	return "no role grants it, needs one of: " + strings.Join(sortedKeys(a.rbac.permissionRoleSet(permission)), ", ")
+}
+
+// Returns the known permission closest to the given one within the max suggestion distance, or an empty string.
+// The permissions are compared in sorted order, so the first of equally close ones wins, and at most
+// maxSuggestionCandidates of them so huge models do not slow down the error path.
+func (r *Rbac) suggestPermission(permission string) string {
+	suggestion := ""
+	suggestionDistance := maxSuggestionDistance + 1
+	compared := 0
+	for _, candidate := range r.permissionsSorted {
+		if compared == maxSuggestionCandidates {
+			break
+		}
+		if diff := len(candidate) - len(permission); diff > maxSuggestionDistance || diff < -maxSuggestionDistance {
+			continue
+		}
+		compared++
+		distance := levenshtein(permission, candidate)
+		if distance < suggestionDistance {
+			suggestion = candidate
+			suggestionDistance = distance
+		}
+	}
+	return suggestion
+}
+
+// Returns the number of single character insertions, deletions and substitutions needed to turn a into b.
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(br)]
+}
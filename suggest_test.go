@@ -0,0 +1,73 @@
+package rbac_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_CheckPermission(t *testing.T) {
+	az := Rbac.Authorizer("use.Account.Member")
+	granted, err := az.CheckPermission("get")
+	if err != nil || !granted {
+		t.Fatal("should have get permission")
+	}
+	granted, err = az.CheckPermission("update")
+	if err != nil || granted {
+		t.Fatal("should not have update permission without error")
+	}
+	_, err = az.CheckPermission("udpate")
+	if err == nil || err.Error() != `unknown permission "udpate", did you mean "update"?` {
+		t.Fatalf("should suggest update, got %v", err)
+	}
+	_, err = az.CheckPermission("something")
+	if err == nil || err.Error() != `unknown permission "something"` {
+		t.Fatalf("should not suggest anything, got %v", err)
+	}
+	_, err = az.CheckPermission("")
+	if err == nil || err.Error() != "empty permission" {
+		t.Fatalf("should reject the empty permission, got %v", err)
+	}
+}
+
+func Test_DenyReason(t *testing.T) {
+	az := Rbac.Authorizer("auth.Authenticated")
+	tests := map[string]string{
+		"create": "",
+		"unknwn": "permission undefined",
+		"delete": "no role grants it, needs one of: use.Account.Admin",
+		"get":    "no role grants it, needs one of: use.Account.Admin, use.Account.Member",
+	}
+	for permission, want := range tests {
+		if got := az.DenyReason(permission); got != want {
+			t.Fatalf("%s: should return %q, got %q", permission, want, got)
+		}
+	}
+	az.AddDeniesAsync(func() ([]string, error) {
+		return []string{"create"}, nil
+	})
+	if got := az.DenyReason("create"); got != "explicitly denied" {
+		t.Fatal("should report a deny, got", got)
+	}
+}
+
+func Test_CheckPermission_Deterministic(t *testing.T) {
+	permissions := []string{}
+	for i := 0; i <= 10000; i++ {
+		permissions = append(permissions, fmt.Sprintf("perm.%05d", i))
+	}
+	r, err := rbac.NewRbac(rbac.Chain("big").AddIndependent("Member", permissions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `unknown permission "perm.0000x", did you mean "perm.00000"?`
+	for i := 0; i < 20; i++ {
+		if _, err := r.Authorizer("big.Member").CheckPermission("perm.0000x"); err == nil || err.Error() != want {
+			t.Fatal("should suggest the first closest permission in sorted order, got", err)
+		}
+	}
+	if _, err := r.Authorizer("big.Member").CheckPermission("perm.1000x"); err == nil || err.Error() == `unknown permission "perm.1000x", did you mean "perm.10000"?` {
+		t.Fatal("should only compare the first sorted candidates, got", err)
+	}
+}
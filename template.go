@@ -0,0 +1,38 @@
+package rbac
+
+import "strings"
+
+// Returns whether one of the roles give the permission of the template with every {name} replaced by its param
+// after waiting for async role additions, e.g. "project:{id}:read" with id "42" checks "project:42:read".
+// Roles may grant a pattern like "project:*:read" with WithMatcher(GlobMatcher{}). A template that refers to a
+// param that is missing or empty, or has an unclosed brace, is denied instead of partially substituted.
+func (a *Authorizer) HasTemplatedPermission(template string, params map[string]string) bool {
+	permission, ok := substitute(template, params)
+	if !ok {
+		return false
+	}
+	return a.HasPermission(permission)
+}
+
+// Returns the template with every {name} replaced by its non-empty param, or false if one is missing.
+func substitute(template string, params map[string]string) (string, bool) {
+	b := &strings.Builder{}
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			b.WriteString(template)
+			return b.String(), true
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			return "", false
+		}
+		value := params[template[start+1:start+end]]
+		if value == "" {
+			return "", false
+		}
+		b.WriteString(template[:start])
+		b.WriteString(value)
+		template = template[start+end+1:]
+	}
+}
@@ -0,0 +1,32 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_HasTemplatedPermission(t *testing.T) {
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("project").AddIndependent("Reader", []string{"project:*:read"}).AddIndependent("Owner", []string{"project:42:write"}),
+	}, rbac.WithMatcher(rbac.GlobMatcher{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := r.Authorizer("project.Reader")
+	if !reader.HasTemplatedPermission("project:{id}:read", map[string]string{"id": "42"}) {
+		t.Fatal("should substitute the params and match the wildcard")
+	}
+	if reader.HasTemplatedPermission("project:{id}:write", map[string]string{"id": "42"}) {
+		t.Fatal("should not match another action")
+	}
+	owner := r.Authorizer("project.Owner")
+	if !owner.HasTemplatedPermission("{kind}:{id}:write", map[string]string{"kind": "project", "id": "42"}) || owner.HasTemplatedPermission("project:{id}:write", map[string]string{"id": "43"}) {
+		t.Fatal("should substitute every param")
+	}
+	for _, template := range []string{"project:{id}:read", "project:{name}:read", "project:{id:read"} {
+		if reader.HasTemplatedPermission(template, map[string]string{"name": ""}) {
+			t.Fatal("should deny a missing, empty or malformed param of", template)
+		}
+	}
+}
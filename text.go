@@ -0,0 +1,43 @@
+package rbac
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returns the role as text in the format {id}={permission},{permission}, e.g. "Member=get,list", or an error if
+// the id contains a = or a permission contains a comma. Only the id and permissions are encoded.
+func (r *Role) MarshalText() ([]byte, error) {
+	if r.Id == "" || strings.Contains(r.Id, "=") {
+		return nil, fmt.Errorf("role id %q cannot be encoded as text", r.Id)
+	}
+	for _, permission := range r.Permissions {
+		if permission == "" || strings.Contains(permission, ",") {
+			return nil, fmt.Errorf("role %s permission %q cannot be encoded as text", r.Id, permission)
+		}
+	}
+	return []byte(r.Id + "=" + strings.Join(r.Permissions, ",")), nil
+}
+
+// Sets the id and permissions of the role from text in the format of MarshalText, e.g. to rebuild a chain with
+// ChainFromRoles. Returns an error for malformed text, i.e. without a =, with an empty id or an empty permission.
+func (r *Role) UnmarshalText(text []byte) error {
+	id, list, ok := strings.Cut(string(text), "=")
+	if !ok {
+		return fmt.Errorf("role text %q is not in the format {id}={permission},{permission}", text)
+	}
+	if id == "" {
+		return fmt.Errorf("role text %q has an empty id", text)
+	}
+	permissions := []string{}
+	if list != "" {
+		permissions = strings.Split(list, ",")
+	}
+	for _, permission := range permissions {
+		if permission == "" {
+			return fmt.Errorf("role text %q has an empty permission", text)
+		}
+	}
+	*r = Role{Id: id, Permissions: permissions}
+	return nil
+}
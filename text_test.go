@@ -0,0 +1,30 @@
+package rbac_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_RoleText(t *testing.T) {
+	text, err := (&rbac.Role{Id: "Member", Permissions: []string{"get", "list"}}).MarshalText()
+	if err != nil || string(text) != "Member=get,list" {
+		t.Fatalf("unexpected text %s, %v", text, err)
+	}
+	role := &rbac.Role{}
+	if err := role.UnmarshalText(text); err != nil || role.Id != "Member" || !reflect.DeepEqual(role.Permissions, []string{"get", "list"}) {
+		t.Fatalf("should round trip, got %+v, %v", role, err)
+	}
+	if err := role.UnmarshalText([]byte("Guest=")); err != nil || role.Id != "Guest" || len(role.Permissions) != 0 {
+		t.Fatalf("should allow roles without permissions, got %+v, %v", role, err)
+	}
+	for _, malformed := range []string{"Member", "=get", "Member=get,,list", "Member=get,"} {
+		if err := role.UnmarshalText([]byte(malformed)); err == nil {
+			t.Fatalf("should reject %q", malformed)
+		}
+	}
+	if _, err := (&rbac.Role{Id: "Member", Permissions: []string{"a,b"}}).MarshalText(); err == nil {
+		t.Fatal("should reject permissions with commas")
+	}
+}
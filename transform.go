@@ -0,0 +1,60 @@
+package rbac
+
+// Returns an option that transforms every permission when the chains are processed, e.g. to lower case them or
+// prefix them with the name of the service, before they are validated and indexed. Checks apply the same transform
+// to the requested permission once, and so do denies, so HasPermission("Read") matches a role giving "read" with
+// strings.ToLower. Introspection returns the transformed permissions while Config returns the permissions of the
+// chains, so building it with the same option gives the same rbac.
+func WithPermissionTransform(transform func(permission string) string) Option {
+	return func(o *options) {
+		o.permissionTransform = transform
+	}
+}
+
+// Returns the permission transformed by the permission transform if there is one.
+func (r *Rbac) transformPermission(permission string) string {
+	if r.config.permissionTransform == nil {
+		return permission
+	}
+	return r.config.permissionTransform(permission)
+}
+
+// Returns a copy of the permissions transformed by the permission transform.
+func (r *Rbac) transformPermissions(permissions []string) []string {
+	transformed := make([]string, len(permissions))
+	for i, permission := range permissions {
+		transformed[i] = r.transformPermission(permission)
+	}
+	return transformed
+}
+
+// Returns a copy of the permissions of the chains transformed by the permission transform, remembering what each
+// was transformed from. Only called while building.
+func (r *Rbac) transformDefined(permissions []string) []string {
+	transformed := r.transformPermissions(permissions)
+	if r.config.permissionTransform == nil {
+		return transformed
+	}
+	if r.permissionToRaw == nil {
+		r.permissionToRaw = map[string]string{}
+	}
+	for i, permission := range permissions {
+		r.permissionToRaw[transformed[i]] = permission
+	}
+	return transformed
+}
+
+// Returns the permissions of the chains the transformed permissions were transformed from.
+func (r *Rbac) rawPermissions(permissions []string) []string {
+	if r.permissionToRaw == nil {
+		return permissions
+	}
+	raw := make([]string, len(permissions))
+	for i, permission := range permissions {
+		raw[i] = permission
+		if original, ok := r.permissionToRaw[permission]; ok {
+			raw[i] = original
+		}
+	}
+	return raw
+}
@@ -0,0 +1,90 @@
+package rbac_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+// Lower cases the permission and prefixes it with the service name if missing.
+func servicePermission(permission string) string {
+	permission = strings.ToLower(strings.TrimSpace(permission))
+	if strings.HasPrefix(permission, "billing.") {
+		return permission
+	}
+	return "billing." + permission
+}
+
+func Test_WithPermissionTransform(t *testing.T) {
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("invoices").Add("Viewer", []string{"Read"}).Add("Editor", []string{" write", "READ"}),
+		rbac.Chain("ops").AddAllExcept("Operator", []string{"Write"}),
+	}, rbac.WithPermissionTransform(servicePermission))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.AllPermissions(), []string{"billing.read", "billing.write"}) {
+		t.Fatal("should transform the registered permissions, got", r.AllPermissions())
+	}
+	az := r.Authorizer("invoices.Viewer")
+	if !az.HasPermission("read") || !az.HasPermission("READ") || !az.HasPermission("billing.read") || az.HasPermission("write") {
+		t.Fatal("should transform the checked permission")
+	}
+	if r.Authorizer("ops.Operator").HasPermission("write") || !r.Authorizer("ops.Operator").HasPermission("read") {
+		t.Fatal("should transform the exclusions of all-except roles")
+	}
+	az = r.Authorizer("invoices.Editor")
+	az.AddDeniesAsync(func() ([]string, error) {
+		return []string{"Write"}, nil
+	})
+	if az.HasPermission("write") {
+		t.Fatal("should transform denies")
+	}
+	_, err = rbac.NewRbacWithOptions([]*rbac.RoleChain{rbac.Chain("invoices").Add("Viewer", []string{" "})}, rbac.WithPermissionTransform(strings.TrimSpace))
+	if err == nil || err.Error() != "role invoices.Viewer has an empty permission" {
+		t.Fatal("should reject permissions transformed to empty, got", err)
+	}
+}
+
+func Test_WithPermissionTransform_NotIdempotent(t *testing.T) {
+	prefix := rbac.WithPermissionTransform(func(permission string) string { return "svc:" + permission })
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("invoices").Add("Viewer", []string{"read", "write"}).AddDeny("Auditor", []string{"write"}),
+	}, prefix, rbac.WithPermissionDescriptions(map[string]string{"read": "reads invoices"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	az := r.Authorizer("invoices.Viewer")
+	if !az.HasPermission("read") || !az.HasPermission("write") || az.HasPermission("svc:read") {
+		t.Fatal("should transform the checked permission exactly once")
+	}
+	if r.Authorizer("invoices.Viewer", "invoices.Auditor").HasPermission("write") {
+		t.Fatal("should not bypass deny roles")
+	}
+	az = r.Authorizer("invoices.Viewer")
+	az.AddDeniesAsync(func() ([]string, error) {
+		return []string{"read"}, nil
+	})
+	if az.HasPermission("read") || !az.HasPermission("write") {
+		t.Fatal("should not bypass async denies")
+	}
+	if description, ok := r.PermissionInfo("read"); !ok || description != "reads invoices" {
+		t.Fatal("should transform described permissions once")
+	}
+	c, err := r.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebuilt, err := c.Build(prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rebuilt.AllPermissions(), []string{"svc:read", "svc:write"}) {
+		t.Fatal("should not transform a config twice, got", rebuilt.AllPermissions())
+	}
+	if rebuilt.Authorizer("invoices.Viewer", "invoices.Auditor").HasPermission("write") {
+		t.Fatal("should keep the deny roles of a config")
+	}
+}
@@ -0,0 +1,48 @@
+package rbac
+
+import "fmt"
+
+// Returns an error describing the first inconsistency of the internal indices, nil if they agree: every role
+// belongs to exactly one chain, every permission is given by an existing role and the role and permission
+// indices mirror each other. Meant as a safety net in tests and after rebuilding or merging rbacs.
+func (r *Rbac) Validate() error {
+	for _, role := range sortedKeys(r.roleToPermissionSet) {
+		chains := []string{}
+		for _, chain := range sortedKeys(r.chainToRoleIdSet) {
+			for roleId := range r.chainToRoleIdSet[chain] {
+				if r.flatten(chain, roleId) == role {
+					chains = append(chains, chain)
+				}
+			}
+		}
+		if len(chains) != 1 {
+			return fmt.Errorf("role %s belongs to %d chains %v", role, len(chains), chains)
+		}
+		if r.roleToChain[role] != chains[0] {
+			return fmt.Errorf("role %s is indexed in chain %q instead of %s", role, r.roleToChain[role], chains[0])
+		}
+		for _, permission := range sortedKeys(r.roleToPermissionSet[role]) {
+			if !r.permissionToRoleSet[permission][role] {
+				return fmt.Errorf("role %s gives permission %q which is not indexed for it", role, permission)
+			}
+		}
+	}
+	for _, chain := range sortedKeys(r.chainToRoleIdSet) {
+		for _, roleId := range sortedKeys(r.chainToRoleIdSet[chain]) {
+			if _, ok := r.roleToPermissionSet[r.flatten(chain, roleId)]; !ok {
+				return fmt.Errorf("chain %s has role id %s without a role", chain, roleId)
+			}
+		}
+	}
+	for _, permission := range sortedKeys(r.permissionToRoleSet) {
+		if len(r.permissionToRoleSet[permission]) == 0 {
+			return fmt.Errorf("permission %q is not given by any role", permission)
+		}
+		for _, role := range sortedKeys(r.permissionToRoleSet[permission]) {
+			if !r.roleToPermissionSet[role][permission] {
+				return fmt.Errorf("permission %q is indexed for role %s which does not give it", permission, role)
+			}
+		}
+	}
+	return nil
+}
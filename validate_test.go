@@ -0,0 +1,44 @@
+package rbac
+
+import "testing"
+
+func Test_Validate(t *testing.T) {
+	build := func() *Rbac {
+		r, err := NewRbac(
+			Chain("auth").Add("Unauthenticated", []string{"list"}).Add("Authenticated", []string{"create"}),
+			Chain("use.Account").Add("Member", []string{"get"}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	if err := build().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]func(r *Rbac){
+		"role auth.Authenticated gives permission \"create\" which is not indexed for it": func(r *Rbac) {
+			delete(r.permissionToRoleSet["create"], "auth.Authenticated")
+		},
+		"permission \"get\" is indexed for role use.Account.Member which does not give it": func(r *Rbac) {
+			delete(r.roleToPermissionSet["use.Account.Member"], "get")
+		},
+		"role use.Account.Member belongs to 0 chains []": func(r *Rbac) {
+			delete(r.chainToRoleIdSet["use.Account"], "Member")
+		},
+		"chain auth has role id Admin without a role": func(r *Rbac) {
+			r.chainToRoleIdSet["auth"]["Admin"] = true
+		},
+		"permission \"delete\" is not given by any role": func(r *Rbac) {
+			r.permissionToRoleSet["delete"] = map[string]bool{}
+		},
+	}
+	for want, corrupt := range tests {
+		r := build()
+		corrupt(r)
+		if err := r.Validate(); err == nil || err.Error() != want {
+			t.Fatalf("should report %q, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package rbac
+
+import "strings"
+
+// The separator of the segments of a wildcard permission.
+const wildcardSeparator = "."
+
+// A node of the trie of the wildcard permissions, one segment per level.
+type wildcardNode struct {
+	// The child of each literal segment.
+	children map[string]*wildcardNode
+	// The child of a * segment in the middle of a permission, matching exactly one segment.
+	any *wildcardNode
+	// The roles giving a permission that ends at this node.
+	roles []string
+	// The roles giving a permission with a trailing * after this node, matching one or more segments.
+	rest []string
+}
+
+// Returns whether the permission has a * segment, e.g. "account.*" or "account.*.read".
+func isWildcard(permission string) bool {
+	for _, segment := range strings.Split(permission, wildcardSeparator) {
+		if segment == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns an option that turns every * segment of a granted permission into a wildcard, so "account.billing.*"
+// and "account.*" give "account.billing.read" and "account.*.read" gives "account.billing.read" but not
+// "account.billing.invoices.read": a * in the middle matches exactly one segment and a trailing * one or more.
+// Exact grants are looked up first. The wildcard permissions are indexed in a trie when building, so a check
+// walks the segments of the requested permission instead of scanning every permission. Introspection like
+// EffectivePermissionsForRole, Permissions and RedundantRoles expands a wildcard permission to the permissions of
+// the known universe it covers. Unlike GlobMatcher, whose * matches any characters and which compares a
+// requested permission against every granted one, it matches whole segments. Without either a * is a literal
+// character, so it is opt-in to not change models that give such permissions.
+func WithWildcardPermissions() Option {
+	return func(o *options) {
+		o.wildcardPermissions = true
+	}
+}
+
+// Builds the trie of the permissions with a * segment, records the roles giving them and expands their
+// effective permissions with the known permissions they cover if wildcard permissions are enabled.
+func (r *Rbac) indexWildcards() {
+	r.wildcardRoleSet = map[string]bool{}
+	if !r.config.wildcardPermissions {
+		return
+	}
+	for permission := range r.permissionToRoleSet {
+		if !isWildcard(permission) {
+			continue
+		}
+		if r.wildcards == nil {
+			r.wildcards = &wildcardNode{}
+		}
+		roles := r.permissionToRolesSorted[permission]
+		if lazy := r.lazyGrantingRoles(permission); len(lazy) > 0 {
+			roles = r.SortRolesBySeniority(append(append([]string{}, roles...), lazy...))
+		}
+		for _, role := range roles {
+			r.wildcardRoleSet[role] = true
+		}
+		node := r.wildcards
+		segments := strings.Split(permission, wildcardSeparator)
+		for i, segment := range segments {
+			switch {
+			case segment == "*" && i == len(segments)-1:
+				node.rest = append(node.rest, roles...)
+				continue
+			case segment == "*":
+				if node.any == nil {
+					node.any = &wildcardNode{}
+				}
+				node = node.any
+			default:
+				if node.children == nil {
+					node.children = map[string]*wildcardNode{}
+				}
+				if node.children[segment] == nil {
+					node.children[segment] = &wildcardNode{}
+				}
+				node = node.children[segment]
+			}
+			if i == len(segments)-1 {
+				node.roles = append(node.roles, roles...)
+			}
+		}
+	}
+	covered := map[string]map[string]bool{}
+	for permission := range r.permissionToRoleSet {
+		for _, role := range r.wildcardGrantingRoles(permission) {
+			if covered[role] == nil {
+				covered[role] = map[string]bool{}
+				for given := range r.rolePermissionSet(role) {
+					covered[role][given] = true
+				}
+			}
+			covered[role][permission] = true
+		}
+	}
+	r.roleToCoveredSet = covered
+}
+
+// Returns the lazily expanded roles that give the permission without listing it, i.e. the ones an eager expansion
+// would have given it to, so they cover the same permissions with a wildcard in both modes.
+func (r *Rbac) lazyGrantingRoles(permission string) []string {
+	roles := []string{}
+	for _, role := range sortedKeys(r.roleToExceptSet) {
+		if !r.roleToExceptSet[role][permission] && !r.roleToPermissionSet[role][permission] {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// Returns the roles giving a wildcard permission that matches the permission, possibly with duplicates. A * segment
+// matches exactly one segment and a trailing * matches one or more.
+func (r *Rbac) wildcardGrantingRoles(permission string) []string {
+	if r.wildcards == nil {
+		return nil
+	}
+	return r.wildcards.collect(strings.Split(permission, wildcardSeparator), nil)
+}
+
+// Appends the roles of the permissions below the node that match the segments.
+func (n *wildcardNode) collect(segments []string, roles []string) []string {
+	if len(segments) == 0 {
+		return append(roles, n.roles...)
+	}
+	roles = append(roles, n.rest...)
+	if child := n.children[segments[0]]; child != nil {
+		roles = child.collect(segments[1:], roles)
+	}
+	if n.any != nil {
+		roles = n.any.collect(segments[1:], roles)
+	}
+	return roles
+}
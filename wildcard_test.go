@@ -0,0 +1,116 @@
+package rbac_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/acudac-com/rbac-go"
+)
+
+func Test_WithWildcardPermissions(t *testing.T) {
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("account").
+			AddIndependent("Billing", []string{"account.billing.*"}).
+			AddIndependent("Owner", []string{"account.*"}).
+			AddIndependent("Reader", []string{"account.*.read"}).
+			AddIndependent("Auditor", []string{"account.billing.read"}),
+	}, rbac.WithWildcardPermissions(), rbac.WithBloomFilter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	billing := r.Authorizer("account.Billing")
+	if !billing.HasPermission("account.billing.read") || !billing.HasPermission("account.billing.invoices.write") {
+		t.Fatal("should give every permission below a trailing wildcard")
+	}
+	if billing.HasPermission("account.billing") || billing.HasPermission("account.users.read") {
+		t.Fatal("should require at least one segment for a trailing wildcard")
+	}
+	if !r.Authorizer("account.Owner").HasPermission("account.users.delete") {
+		t.Fatal("should give permissions below a broader wildcard")
+	}
+	reader := r.Authorizer("account.Reader")
+	if !reader.HasPermission("account.users.read") || reader.HasPermission("account.billing.invoices.read") || reader.HasPermission("account.users.write") {
+		t.Fatal("should match exactly one segment with a wildcard in the middle")
+	}
+	if !r.Authorizer("account.Auditor").HasPermission("account.billing.read") {
+		t.Fatal("should still give exact permissions")
+	}
+	if !reflect.DeepEqual(r.Authorizer("account.Auditor", "account.Billing", "account.Reader").WhoGrants("account.billing.read"), []string{"account.Auditor", "account.Billing", "account.Reader"}) {
+		t.Fatal("should report the roles granting with a wildcard")
+	}
+	if !reader.HasPermissionOn("doc", "account.users.read") {
+		t.Fatal("should match wildcards of scoped checks")
+	}
+	if granted, err := billing.CheckPermission("account.billing.refund"); !granted || err != nil {
+		t.Fatal("should treat wildcard permissions as defined", err)
+	}
+
+	literal, _ := rbac.NewRbac(rbac.Chain("account").Add("Billing", []string{"account.billing.*"}))
+	if literal.Authorizer("account.Billing").HasPermission("account.billing.read") {
+		t.Fatal("should treat * literally without the option")
+	}
+}
+
+func Test_WithWildcardPermissions_Introspection(t *testing.T) {
+	r, err := rbac.NewRbacWithOptions([]*rbac.RoleChain{
+		rbac.Chain("account").
+			AddIndependent("Reader", []string{"account.billing.read", "account.users.read"}).
+			AddIndependent("Writer", []string{"account.billing.write"}).
+			AddIndependent("Billing", []string{"account.billing.*"}).
+			AddIndependent("AllBilling", []string{"account.billing.read", "account.billing.write", "account.billing.*"}),
+	}, rbac.WithWildcardPermissions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"account.billing.*", "account.billing.read", "account.billing.write"}
+	if got := r.EffectivePermissionsForRole("account.Billing"); !reflect.DeepEqual(got, want) {
+		t.Fatal("should expand a wildcard against the known universe, got", got)
+	}
+	if got := r.Authorizer("account.Billing").Permissions(); !reflect.DeepEqual(got, want) {
+		t.Fatal("should expand a wildcard in the permissions of an authorizer, got", got)
+	}
+	if !reflect.DeepEqual(r.RedundantRoles(), [][2]string{{"account.AllBilling", "account.Billing"}}) {
+		t.Fatal("should compare the expanded permissions for redundancy, got", r.RedundantRoles())
+	}
+	if !r.Authorizer("account.Billing").EquivalentTo(r.Authorizer("account.AllBilling")) {
+		t.Fatal("should compare the expanded permissions for equivalence")
+	}
+	if !reflect.DeepEqual(r.RolesWithPermission("account.billing.write"), []string{"account.AllBilling", "account.Billing", "account.Writer"}) {
+		t.Fatal("should list roles covering the permission with a wildcard, got", r.RolesWithPermission("account.billing.write"))
+	}
+}
+
+func Test_WithWildcardPermissions_Lazy(t *testing.T) {
+	chains := func() []*rbac.RoleChain {
+		return []*rbac.RoleChain{
+			rbac.Chain("account").Add("Viewer", []string{"account.users.read"}).Add("Billing", []string{"account.billing.*"}),
+			rbac.Chain("ops").AddAllExcept("Admin", []string{"account.users.read"}),
+			rbac.Chain("support").AddAllExcept("Agent", []string{"account.billing.*"}),
+		}
+	}
+	eager, err := rbac.NewRbacWithOptions(chains(), rbac.WithWildcardPermissions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lazy, err := rbac.NewRbacWithOptions(chains(), rbac.WithWildcardPermissions(), rbac.WithLazyExpansion())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []*rbac.Rbac{eager, lazy} {
+		admin, agent := r.Authorizer("ops.Admin"), r.Authorizer("support.Agent")
+		if !admin.HasPermission("account.billing.refund") || admin.HasPermission("account.users.read") {
+			t.Fatal("an all-except role should give the permissions covered by a wildcard it does not except")
+		}
+		if agent.HasPermission("account.billing.refund") || !agent.HasPermission("account.users.read") {
+			t.Fatal("an all-except role should not give the permissions covered by a wildcard it excepts")
+		}
+	}
+	for _, role := range []string{"ops.Admin", "support.Agent"} {
+		if got, want := lazy.EffectivePermissionsForRole(role), eager.EffectivePermissionsForRole(role); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: should expand the same permissions lazily, got %v, want %v", role, got, want)
+		}
+	}
+	if got, want := lazy.RolesWithPermission("account.billing.refund"), eager.RolesWithPermission("account.billing.refund"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("should list the same roles lazily, got %v, want %v", got, want)
+	}
+}